@@ -0,0 +1,57 @@
+package sdiffer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxAggregateSamples = 5
+
+var indexRegexp = regexp.MustCompile(`\[[0-9]+\]`)
+
+// WithAggregation collapse diffs with the same path pattern and the same values into one diff,
+// path pattern is the path with all slice indices replaced by "[*]".
+// The first diff found is kept, with Count and Samples recording the collapsed ones.
+//
+// For example:
+// Person.Parents[0].Age and Person.Parents[3].Age, both A: 1, B: 2
+// => Person.Parents[0].Age with Count 2 and Samples [[0] [3]]
+func (d *Differ) WithAggregation() *Differ {
+	d.aggregated = true
+	return d
+}
+
+// aggregate merges df into an existing diff with the same pattern and values,
+// it returns false if there is no such diff.
+func (d *Differ) aggregate(df *Diff) (merged bool) {
+	pattern := indexRegexp.ReplaceAllString(df.name, "[*]")
+	key := concat(pattern, "\x00", toString(df.Va()), "\x00", toString(df.Vb()))
+	if d.aggGroups == nil {
+		d.aggGroups = make(map[string]*Diff, 16)
+	}
+	group, ok := d.aggGroups[key]
+	if !ok {
+		df.samples = []string{pathIndices(df.name)}
+		d.aggGroups[key] = df
+		return false
+	}
+	group.count++
+	if len(group.samples) < maxAggregateSamples {
+		group.samples = append(group.samples, pathIndices(df.name))
+	}
+	return true
+}
+
+// pathIndices returns all slice indices in fieldPath, such as:
+// Person.Parents[1].Loc[2] => [1][2]
+func pathIndices(fieldPath string) string {
+	return strings.Join(indexRegexp.FindAllString(fieldPath, -1), "")
+}
+
+func aggregationSuffix(df *Diff) string {
+	if df.count <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d times, e.g. %s)", df.count, strings.Join(df.samples, " "))
+}
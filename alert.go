@@ -0,0 +1,142 @@
+package sdiffer
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Severity is how severe a diff is, see Alerter.
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+)
+
+// SeverityFunc returns the Severity of a diff.
+type SeverityFunc func(df *Diff) Severity
+
+// AlertRule fires an alert when too many results have diffs of interest within a time window.
+// A diff is of interest if its path matches Path and its Severity is not lower than MinSeverity.
+type AlertRule struct {
+	Name string
+
+	// Path is the regexp of paths of diffs of interest, blank means all.
+	Path string
+
+	MinSeverity Severity
+
+	// MaxCount fires the alert when the number of results with diffs of interest exceeds it, 0 means no limit.
+	MaxCount int
+
+	// MaxRatio fires the alert when the ratio of results with diffs of interest exceeds it, 0 means no limit.
+	MaxRatio float64
+
+	// Window is the time window results are aggregated in.
+	Window time.Duration
+}
+
+// Alert is fired by Alerter when an AlertRule is violated.
+type Alert struct {
+	Rule  AlertRule
+	Count int
+	Total int
+	Ratio float64
+	At    time.Time
+}
+
+type alertState struct {
+	rule      AlertRule
+	re        *regexp.Regexp
+	results   []time.Time
+	matches   []time.Time
+	lastFired time.Time
+}
+
+// Alerter aggregates results of comparisons across a time window, and calls a callback when
+// an AlertRule is violated, e.g. when more than 1% of shadow responses differ on Amount.
+// An alert fires at most once per window of its rule. Alerter implements Sink so that it can be
+// set by Differ.WithSink, and it is safe for concurrent use.
+type Alerter struct {
+	mu       sync.Mutex
+	severity SeverityFunc
+	fire     func(alert Alert)
+	states   []*alertState
+	now      func() time.Time
+}
+
+// NewAlerter creates an Alerter, severity can be nil, which treats every diff as SeverityLow.
+func NewAlerter(severity SeverityFunc, fire func(alert Alert)) *Alerter {
+	if severity == nil {
+		severity = func(*Diff) Severity { return SeverityLow }
+	}
+	return &Alerter{severity: severity, fire: fire, now: time.Now}
+}
+
+// AddRule adds an AlertRule, it panics if rule.Path is not a valid regexp.
+func (a *Alerter) AddRule(rule AlertRule) *Alerter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := &alertState{rule: rule}
+	if !isStringBlank(rule.Path) {
+		st.re = regexp.MustCompile(rule.Path)
+	}
+	a.states = append(a.states, st)
+	return a
+}
+
+// Write records res and fires alerts of rules violated.
+func (a *Alerter) Write(res *Result) error {
+	a.mu.Lock()
+	now := a.now()
+	var alerts []Alert
+	for _, st := range a.states {
+		st.results = append(pruneBefore(st.results, now.Add(-st.rule.Window)), now)
+		st.matches = pruneBefore(st.matches, now.Add(-st.rule.Window))
+		if a.matches(st, res) {
+			st.matches = append(st.matches, now)
+		}
+		if alert, ok := st.check(now); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	a.mu.Unlock()
+	for _, alert := range alerts {
+		a.fire(alert)
+	}
+	return nil
+}
+
+func (a *Alerter) matches(st *alertState, res *Result) bool {
+	for _, df := range res.Diffs {
+		if (st.re == nil || st.re.MatchString(df.name)) && a.severity(df) >= st.rule.MinSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks if the rule is violated, and marks it fired if so.
+func (st *alertState) check(now time.Time) (Alert, bool) {
+	if !st.lastFired.IsZero() && now.Sub(st.lastFired) < st.rule.Window {
+		return Alert{}, false
+	}
+	count, total := len(st.matches), len(st.results)
+	ratio := float64(count) / float64(total)
+	if (st.rule.MaxCount <= 0 || count <= st.rule.MaxCount) && (st.rule.MaxRatio <= 0 || ratio <= st.rule.MaxRatio) {
+		return Alert{}, false
+	}
+	st.lastFired = now
+	return Alert{Rule: st.rule, Count: count, Total: total, Ratio: ratio, At: now}, true
+}
+
+// pruneBefore removes times before t from the sorted times.
+func pruneBefore(times []time.Time, t time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(t) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,108 @@
+package sdiffer
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FindDiffs finds all diffs of a logical field, sorted by path. field can be:
+// the path with or without suffix markers such as "[Length]" and ".$[customized]",
+// the path with JSON names of struct fields, such as "Person.parents[0].name",
+// or a label set by WithPathLabels.
+func (d *Differ) FindDiffs(field string) []*Diff {
+	keys := map[string]struct{}{normalizePath(field): {}}
+	if d.rootType != nil {
+		if p, ok := translateJSONPath(d.rootType, field); ok {
+			keys[p] = struct{}{}
+		}
+	}
+	for path, label := range d.pathLabels {
+		if label == field {
+			keys[path] = struct{}{}
+		}
+	}
+	var dfs []*Diff
+	for _, df := range d.diffs {
+		p := normalizePath(df.name)
+		_, ok := keys[p]
+		if !ok {
+			_, ok = keys[(&Diff{name: p}).Tag()]
+		}
+		if ok {
+			dfs = append(dfs, df)
+		}
+	}
+	sortDiffs(dfs)
+	return dfs
+}
+
+// normalizePath removes suffix markers of fieldPath.
+func normalizePath(fieldPath string) string {
+	for _, suffix := range []string{useComparatorSuffix, lengthSuffix, shiftSuffix, indirectionSuffix} {
+		fieldPath = strings.TrimSuffix(fieldPath, suffix)
+	}
+	return fieldPath
+}
+
+// translateJSONPath translates JSON names of struct fields in fieldPath into Go names
+// with the type of the root t, it returns false if the root of fieldPath is not t.
+func translateJSONPath(t reflect.Type, fieldPath string) (string, bool) {
+	root := rootTypeName(t)
+	if !strings.HasPrefix(fieldPath, root) {
+		return "", false
+	}
+	builder := &strings.Builder{}
+	builder.WriteString(root)
+	rest := normalizePath(fieldPath[len(root):])
+	for rest != "" {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			token := rest[1 : end+1]
+			rest = rest[end+1:]
+			if t == nil || t.Kind() != reflect.Struct {
+				builder.WriteString("." + token)
+				t = nil
+				continue
+			}
+			sf, ok := fieldByJSONName(t, token)
+			if !ok {
+				return "", false
+			}
+			builder.WriteString("." + sf.Name)
+			t = sf.Type
+		case '[':
+			end := closingBracket(rest)
+			if end < 0 {
+				return "", false
+			}
+			builder.WriteString(rest[:end+1])
+			rest = rest[end+1:]
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+				t = t.Elem()
+			} else {
+				t = nil
+			}
+		default:
+			return "", false
+		}
+	}
+	return builder.String(), true
+}
+
+// fieldByJSONName finds the struct field of t whose JSON name or Go name is name.
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if jsonName := strings.Split(sf.Tag.Get("json"), ",")[0]; jsonName == name {
+			return sf, true
+		}
+	}
+	return t.FieldByName(name)
+}
@@ -0,0 +1,40 @@
+package sdiffer
+
+// Pair is a pair of values to compare, see Differ.CompareAll.
+type Pair struct {
+	A interface{}
+	B interface{}
+}
+
+// BatchResult is the results of Differ.CompareAll, Results[i] is the result of pairs[i].
+type BatchResult struct {
+	Results []*Result
+}
+
+// HasDiffs checks if any pair has diffs.
+func (br *BatchResult) HasDiffs() bool {
+	return len(br.Indices()) > 0
+}
+
+// Indices returns indices of pairs which have diffs.
+func (br *BatchResult) Indices() (indices []int) {
+	for i, r := range br.Results {
+		if r.HasDiffs() {
+			indices = append(indices, i)
+		}
+	}
+	return
+}
+
+// CompareAll compares many pairs with the configuration of Differ, one by one.
+// Differ itself is not changed, and the configuration is shared by all pairs
+// instead of being copied for each pair.
+func (d *Differ) CompareAll(pairs []Pair) *BatchResult {
+	cd := d.clone()
+	br := &BatchResult{Results: make([]*Result, 0, len(pairs))}
+	for _, p := range pairs {
+		cd.resetResult()
+		br.Results = append(br.Results, cd.Compare(p.A, p.B).Result())
+	}
+	return br
+}
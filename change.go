@@ -0,0 +1,118 @@
+package sdiffer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValueChange classifies the nature of the change of a diff, see Diff.Change.
+type ValueChange int
+
+const (
+	// OtherChange means the change is not classified.
+	OtherChange ValueChange = iota
+
+	// Increased means B is a number greater than A.
+	Increased
+
+	// Decreased means B is a number less than A.
+	Decreased
+
+	// CaseOnly means A and B are strings which differ only in case.
+	CaseOnly
+
+	// WhitespaceOnly means A and B are strings which differ only in whitespaces.
+	WhitespaceOnly
+)
+
+func (vc ValueChange) String() string {
+	switch vc {
+	case OtherChange:
+		return "other"
+	case Increased:
+		return "increased"
+	case Decreased:
+		return "decreased"
+	case CaseOnly:
+		return "case-only"
+	case WhitespaceOnly:
+		return "whitespace-only"
+	}
+	return fmt.Sprintf("ValueChange(%d)", int(vc))
+}
+
+// Change classifies the change from A to B: Increased or Decreased with delta B - A for numbers,
+// CaseOnly or WhitespaceOnly for strings, or else OtherChange.
+func (d *Diff) Change() (change ValueChange, delta float64) {
+	if d.dt != ElemDiff {
+		return OtherChange, 0
+	}
+	if delta, _, ok := d.Delta(); ok {
+		switch {
+		case delta > 0:
+			return Increased, delta
+		case delta < 0:
+			return Decreased, delta
+		}
+		return OtherChange, 0
+	}
+	va, vb := reflect.ValueOf(d.Va()), reflect.ValueOf(d.Vb())
+	if va.Kind() != reflect.String || vb.Kind() != reflect.String || va.String() == vb.String() {
+		return OtherChange, 0
+	}
+	sa, sb := va.String(), vb.String()
+	if strings.Join(strings.Fields(sa), "") == strings.Join(strings.Fields(sb), "") {
+		return WhitespaceOnly, 0
+	}
+	if strings.EqualFold(sa, sb) {
+		return CaseOnly, 0
+	}
+	return OtherChange, 0
+}
+
+// WithChangeClassification appends the classification of changes of diffs to report lines,
+// such as " (decreased by 2.5)" and " (case-only)", see Diff.Change.
+func (d *Differ) WithChangeClassification() *Differ {
+	d.classified = true
+	return d
+}
+
+// changeSuffix returns the suffix of report lines of df if WithChangeClassification is called.
+func (d *Differ) changeSuffix(df *Diff) string {
+	if !d.classified {
+		return ""
+	}
+	switch change, delta := df.Change(); change {
+	case Increased, Decreased:
+		return fmt.Sprintf(" (%s by %s)", change, strconv.FormatFloat(math.Abs(delta), 'g', -1, 64))
+	case CaseOnly, WhitespaceOnly:
+		return concat(" (", change.String(), ")")
+	}
+	return ""
+}
+
+// ChangeIs keeps diffs whose change is any of changes, see Diff.Change.
+func (q *Query) ChangeIs(changes ...ValueChange) *Query {
+	return q.Where(func(df *Diff) bool {
+		change, _ := df.Change()
+		for _, c := range changes {
+			if change == c {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func isNumberKind(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
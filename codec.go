@@ -0,0 +1,101 @@
+package sdiffer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec decodes serialized payloads for Differ.CompareEncoded.
+// Payloads of msgpack, cbor or proto can be supported by wrapping their decoders with Decoder,
+// and NormalizeNumbers if the decoded numbers have various widths.
+type Codec interface {
+	Decode(data []byte) (interface{}, error)
+}
+
+// Decode makes Decoder a Codec.
+func (fn Decoder) Decode(data []byte) (interface{}, error) {
+	return fn(data)
+}
+
+// JSONCodec decodes JSON payloads into interface{}.
+var JSONCodec Codec = Decoder(func(data []byte) (v interface{}, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+})
+
+// GobCodec decodes gob payloads into the value returned by newValue, which must be a pointer.
+func GobCodec(newValue func() interface{}) Codec {
+	return Decoder(func(data []byte) (interface{}, error) {
+		v := newValue()
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+}
+
+// NormalizeNumbers wraps codec to normalize the decoded value: numbers of all widths are converted
+// into float64, and maps with interface{} keys are converted into map[string]interface{},
+// just like values decoded from JSON. It helps to compare payloads such as msgpack.
+func NormalizeNumbers(codec Codec) Codec {
+	return Decoder(func(data []byte) (interface{}, error) {
+		v, err := codec.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeNumbers(reflect.ValueOf(v)), nil
+	})
+}
+
+func normalizeNumbers(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return normalizeNumbers(v.Elem())
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[formatKey(k)] = normalizeNumbers(v.MapIndex(k))
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		arr := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			arr = append(arr, normalizeNumbers(v.Index(i)))
+		}
+		return arr
+	}
+	if f, ok := toFloat(v); ok && v.Kind() != reflect.String {
+		return f
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return toString(v)
+}
+
+// CompareEncoded decodes a and b with codec and compares them like Compare. A nil root on only one side
+// is recorded as a NilDiff at "$", and values of different types, such as roots of null and {},
+// are recorded as ElemDiffs like CompareJSON does.
+func (d *Differ) CompareEncoded(a, b []byte, codec Codec) (*Differ, error) {
+	va, err := codec.Decode(a)
+	if err != nil {
+		return d, fmt.Errorf("decode payload a: %w", err)
+	}
+	vb, err := codec.Decode(b)
+	if err != nil {
+		return d, fmt.Errorf("decode payload b: %w", err)
+	}
+	return d.compareDecoded(va, vb), nil
+}
@@ -1,5 +1,12 @@
 package sdiffer
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
 type DiffType int
 
 const (
@@ -14,16 +21,107 @@ const (
 
 	// NoDiff should be returned by Comparator.Equals when two elements are equal.
 	NoDiff
+
+	// UncomparableDiff is recorded when Differ panics when comparing two elements, see Differ.WithRecover.
+	UncomparableDiff
+
+	// MissingDiff is recorded for elements beyond the shorter length of two Slices,
+	// which exist only in one of them.
+	MissingDiff
+
+	// ShiftDiff is recorded when elements are inserted at the head of one of two Slices,
+	// see Differ.WithShiftDetection.
+	ShiftDiff
+
+	// CrossFieldDiff is recorded when a relation between fields is violated, see Differ.WithCrossFieldRule.
+	CrossFieldDiff
+
+	// SorterDiff is recorded when Sorter.Less panics when sorting a Slice, see Differ.WithSorterRecover.
+	SorterDiff
+)
+
+// customDiffTypeBase is the first DiffType returned by RegisterDiffType.
+const customDiffTypeBase DiffType = 100
+
+var (
+	diffTypeMu    sync.RWMutex
+	nextDiffType  = customDiffTypeBase
+	diffTypeNames = map[DiffType]string{
+		LengthDiff:       "LengthDiff",
+		NilDiff:          "NilDiff",
+		ElemDiff:         "ElemDiff",
+		NoDiff:           "NoDiff",
+		UncomparableDiff: "UncomparableDiff",
+		MissingDiff:      "MissingDiff",
+		ShiftDiff:        "ShiftDiff",
+		CrossFieldDiff:   "CrossFieldDiff",
+		SorterDiff:       "SorterDiff",
+	}
 )
 
+// RegisterDiffType registers a customized DiffType with name, which can be returned by Comparator.Equals
+// and flows through reports and JSON output. The same DiffType is returned if name is registered.
+//
+// For example:
+// var FormatDiff = sdiffer.RegisterDiffType("FormatDiff")
+func RegisterDiffType(name string) DiffType {
+	diffTypeMu.Lock()
+	defer diffTypeMu.Unlock()
+	for dt, n := range diffTypeNames {
+		if n == name {
+			return dt
+		}
+	}
+	dt := nextDiffType
+	nextDiffType++
+	diffTypeNames[dt] = name
+	return dt
+}
+
+// isRegistered checks if dt is a built-in or registered DiffType.
+func (dt DiffType) isRegistered() bool {
+	diffTypeMu.RLock()
+	defer diffTypeMu.RUnlock()
+	_, ok := diffTypeNames[dt]
+	return ok
+}
+
+func (dt DiffType) String() string {
+	diffTypeMu.RLock()
+	defer diffTypeMu.RUnlock()
+	if name, ok := diffTypeNames[dt]; ok {
+		return name
+	}
+	return "DiffType(" + strconv.Itoa(int(dt)) + ")"
+}
+
+// MarshalText marshals DiffType as its name.
+func (dt DiffType) MarshalText() ([]byte, error) {
+	return []byte(dt.String()), nil
+}
+
+// UnmarshalText unmarshals DiffType from its name.
+func (dt *DiffType) UnmarshalText(text []byte) error {
+	diffTypeMu.RLock()
+	defer diffTypeMu.RUnlock()
+	for t, name := range diffTypeNames {
+		if name == string(text) {
+			*dt = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown DiffType: %s", text)
+}
+
 // Comparator customized field comparator.
 type Comparator interface {
 
 	// Match checks if a field should use this comparator.
 	Match(fieldPath string) bool
 
-	// Equals compares two interfaces and return a DiffType among LengthDiff, NilDiff, ElemDiff
-	// and NoDiff, or else Differ will throw a panic.
+	// Equals compares two interfaces and return a DiffType among LengthDiff, NilDiff, ElemDiff,
+	// NoDiff and DiffTypes registered by RegisterDiffType, or else an error will be recorded, see Differ.Errors.
+	// msgA, msgB are recorded as the diff for registered DiffTypes just like ElemDiff.
 	//
 	// Attention:
 	// msgA, msgB represent the diff msg you want to record when dt is ElemDiff, which means
@@ -32,3 +130,106 @@ type Comparator interface {
 	// See Differ.Compare for more details.
 	Equals(a, b interface{}) (dt DiffType, msgA, msgB interface{})
 }
+
+// ComparatorE is a Comparator which can report errors, EqualsE is called instead of Equals if a Comparator
+// implements it. Errors are recorded as UncomparableDiff of the field path and collected by Differ.Errors,
+// rather than crashing the comparison.
+type ComparatorE interface {
+	Comparator
+
+	// EqualsE works like Comparator.Equals, and returns an error if a and b cannot be compared.
+	EqualsE(a, b interface{}) (dt DiffType, msgA, msgB interface{}, err error)
+}
+
+// CompareError is an error occurred when comparing a field.
+type CompareError struct {
+	Path string
+	Err  error
+}
+
+func (e *CompareError) Error() string {
+	return fmt.Sprintf("compare %s: %v", e.Path, e.Err)
+}
+
+func (e *CompareError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns errors occurred when comparing with Comparators, including unexpected DiffTypes returned.
+func (d *Differ) Errors() []*CompareError {
+	return d.errs
+}
+
+// WithComparatorSuffix appends ".$[customized]" to paths of diffs found by Comparators,
+// which is the legacy behavior, see Diff.ByComparator for the replacement.
+func (d *Differ) WithComparatorSuffix() *Differ {
+	d.comparatorSuffix = true
+	return d
+}
+
+// compareWith compares a and b with c, diffs found are marked as found by Comparator.
+func (d *Differ) compareWith(c Comparator, a, b reflect.Value, fieldPath string) {
+	if d.comparatorSuffix {
+		fieldPath = fieldPath + useComparatorSuffix
+	}
+	d.byComparator = true
+	defer func() {
+		d.byComparator = false
+	}()
+	var (
+		dt     DiffType
+		va, vb interface{}
+		err    error
+	)
+	if ce, ok := c.(ComparatorE); ok {
+		dt, va, vb, err = ce.EqualsE(a.Interface(), b.Interface())
+	} else {
+		dt, va, vb = c.Equals(a.Interface(), b.Interface())
+	}
+	if err != nil {
+		d.setCompareErr(fieldPath, err)
+		return
+	}
+	switch dt {
+	case LengthDiff:
+		d.setLenDiff(fieldPath, a, b)
+	case NilDiff:
+		d.setNilDiff(fieldPath, a, b)
+	case ElemDiff:
+		d.setDiff(fieldPath, va, vb)
+	case NoDiff:
+		d.setEqual(fieldPath, a, b)
+	default:
+		if !dt.isRegistered() {
+			d.setCompareErr(fieldPath, fmt.Errorf("customized comparator returned an unexpected DiffType: %v", dt))
+			return
+		}
+		d.setTypedDiff(dt, fieldPath, va, vb)
+	}
+}
+
+// setCompareErr records err as an UncomparableDiff of fieldName and collects it, see Differ.Errors.
+func (d *Differ) setCompareErr(fieldName string, err error) {
+	d.errs = append(d.errs, &CompareError{Path: fieldName, Err: err})
+	d.setTypedDiff(UncomparableDiff, fieldName, err.Error(), err.Error())
+}
+
+// WithTypeComparer compares values with a customized function wherever their type appears,
+// fn must be like func(a, b T) bool, which returns true when a and b are equal, or else
+// WithTypeComparer will panic. Unlike Comparator, it does not break when the struct layout changes.
+//
+// For example:
+// differ.WithTypeComparer(func(a, b time.Time) bool { return a.Equal(b) })
+func (d *Differ) WithTypeComparer(fn interface{}) *Differ {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.In(0) != ft.In(1) ||
+		ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Bool {
+		panic("type comparer must be like func(a, b T) bool, got: " + ft.String())
+	}
+	if d.typeComparers == nil {
+		d.typeComparers = make(map[reflect.Type]reflect.Value)
+	}
+	d.typeComparers[ft.In(0)] = fv
+	return d
+}
@@ -0,0 +1,142 @@
+package sdiffer
+
+import "reflect"
+
+// Clone returns an independent copy of the configuration of Differ without any compare result,
+// rule lists are copied so that the copy can be changed without affecting Differ, and vice versa.
+// It is safe to share a base configuration across packages by cloning it.
+func (d *Differ) Clone() *Differ {
+	return d.clone()
+}
+
+// Merge composes the configuration of other into Differ:
+// rules such as ignores, includes, comparators and sorters of other are appended,
+// maps such as unwraps, type comparers and path labels are merged with other taking precedence,
+// switches such as WithRecover are turned on if they are on in other,
+// and settings such as max depth, templates and label are taken from other if they are set.
+func (d *Differ) Merge(other *Differ) *Differ {
+	if other.fullMatch {
+		d.WithFullMatch()
+	}
+	if other.foldCase {
+		d.WithCaseInsensitive()
+	}
+	d.ignores = append(d.ignores, other.ignores...)
+	d.includes = append(d.includes, other.includes...)
+	d.trimSpaces = append(d.trimSpaces, other.trimSpaces...)
+	d.trimTags = append(d.trimTags, other.trimTags...)
+	d.comparators = append(d.comparators, other.comparators...)
+	d.sorters = append(d.sorters, other.sorters...)
+	d.mapSorters = append(d.mapSorters, other.mapSorters...)
+	d.redacts = append(d.redacts, other.redacts...)
+	d.unitAwares = append(d.unitAwares, other.unitAwares...)
+	d.normalizers = append(d.normalizers, other.normalizers...)
+	d.dateFormats = append(d.dateFormats, other.dateFormats...)
+	d.mustEquals = append(d.mustEquals, other.mustEquals...)
+	d.expecteds = append(d.expecteds, other.expecteds...)
+	d.crossRules = append(d.crossRules, other.crossRules...)
+	d.suppressions = append(d.suppressions, other.suppressions...)
+	d.scopedIgnores = append(d.scopedIgnores, other.scopedIgnores...)
+	d.shifts = append(d.shifts, other.shifts...)
+	d.sinks = append(d.sinks, other.sinks...)
+	d.tracePaths = append(d.tracePaths, other.tracePaths...)
+	d.nullAsZeros = append(d.nullAsZeros, other.nullAsZeros...)
+	d.keyFormats = append(d.keyFormats, other.keyFormats...)
+	d.methods = append(d.methods, other.methods...)
+	d.textuals = append(d.textuals, other.textuals...)
+	d.errorRules = append(d.errorRules, other.errorRules...)
+	d.syncMaps = append(d.syncMaps, other.syncMaps...)
+	d.skipTypes = append(d.skipTypes, other.skipTypes...)
+	d.tolerances = append(d.tolerances, other.tolerances...)
+	d.lenTolerances = append(d.lenTolerances, other.lenTolerances...)
+	d.deltas = append(d.deltas, other.deltas...)
+	d.keyMatchers = append(d.keyMatchers, other.keyMatchers...)
+	d.timeWindows = append(d.timeWindows, other.timeWindows...)
+	d.postHooks = append(d.postHooks, other.postHooks...)
+	d.csvKeys = append(d.csvKeys, other.csvKeys...)
+	d.migrations = append(d.migrations, other.migrations...)
+	d.pathTmpls = append(d.pathTmpls, other.pathTmpls...)
+	d.sections = append(d.sections, other.sections...)
+
+	d.WithPathLabels(other.pathLabels)
+	for t, fn := range other.unwraps {
+		if d.unwraps == nil {
+			d.unwraps = make(map[reflect.Type]Unwrapper, len(other.unwraps))
+		}
+		d.unwraps[t] = fn
+	}
+	for name, fn := range other.namedUnwraps {
+		d.WithUnwrapByName(name, fn)
+	}
+	for kind, h := range other.kindHandlers {
+		d.RegisterKindHandler(kind, h)
+	}
+	for _, fn := range other.typeComparers {
+		d.WithTypeComparer(fn.Interface())
+	}
+
+	d.reportEqual = d.reportEqual || other.reportEqual
+	d.comparatorSuffix = d.comparatorSuffix || other.comparatorSuffix
+	d.redactSecrets = d.redactSecrets || other.redactSecrets
+	d.aggregated = d.aggregated || other.aggregated
+	d.flushPerField = d.flushPerField || other.flushPerField
+	d.sectioned = d.sectioned || other.sectioned
+	d.quiet = d.quiet || other.quiet
+	d.robust = d.robust || other.robust
+	d.sorterRecover = d.sorterRecover || other.sorterRecover
+	d.memoized = d.memoized || other.memoized
+	d.correlated = d.correlated || other.correlated
+	d.classified = d.classified || other.classified
+	d.positioned = d.positioned || other.positioned
+	d.expandNil = d.expandNil || other.expandNil
+	d.recordVisited = d.recordVisited || other.recordVisited
+	d.compareInfra = d.compareInfra || other.compareInfra
+
+	if other.maxDepth != defaultDepthLimit {
+		d.maxDepth = other.maxDepth
+	}
+	if other.maxDiffs > 0 {
+		d.maxDiffs = other.maxDiffs
+	}
+	if other.contextSize > 0 {
+		d.contextSize = other.contextSize
+	}
+	if !isStringBlank(other.diffTmpl) {
+		d.diffTmpl = other.diffTmpl
+	}
+	if other.tmpl != nil {
+		d.tmpl = other.tmpl
+	}
+	if other.reportTmpl != nil {
+		d.reportTmpl = other.reportTmpl
+	}
+	if !isStringBlank(other.label) {
+		d.label = other.label
+	}
+	if other.stream != nil {
+		d.stream = other.stream
+	}
+	if other.tracer != nil {
+		d.tracer = other.tracer
+	}
+	if !isStringBlank(other.fixedID) {
+		d.fixedID = other.fixedID
+	}
+	if other.sourceA != nil || other.sourceB != nil {
+		d.sourceA, d.sourceB = other.sourceA, other.sourceB
+	}
+	if other.maxReportBytes > 0 {
+		d.maxReportBytes = other.maxReportBytes
+	}
+	if other.severity != nil {
+		d.severity = other.severity
+	}
+	if other.mapHashing != nil {
+		d.mapHashing = other.mapHashing
+	}
+	if other.pathStyle != nil {
+		ps := *other.pathStyle
+		d.pathStyle = &ps
+	}
+	return d
+}
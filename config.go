@@ -0,0 +1,88 @@
+package sdiffer
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redacted       = "<redacted>"
+	configLabel    = "config drift"
+	configDiffTmpl = `Config "%s" drifted: %v => %v`
+)
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"K":   1e3,
+	"KB":  1e3,
+	"M":   1e6,
+	"MB":  1e6,
+	"G":   1e9,
+	"GB":  1e9,
+	"T":   1e12,
+	"TB":  1e12,
+	"Ki":  1 << 10,
+	"KiB": 1 << 10,
+	"Mi":  1 << 20,
+	"MiB": 1 << 20,
+	"Gi":  1 << 30,
+	"GiB": 1 << 30,
+	"Ti":  1 << 40,
+	"TiB": 1 << 40,
+}
+
+var byteSizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([KMGT]i?B?|B)?$`)
+
+// CompareConfig compares two configuration structs and returns a Differ whose String is a drift report.
+// Comparing with CompareConfig:
+// values of fields tagged `secret:"true"` are redacted in diffs,
+// durations and byte sizes written differently but equal in value, such as "5s" and "5000ms",
+// "1Gi" and "1073741824", are treated as equal.
+func CompareConfig(a, b interface{}, opts ...Option) *Differ {
+	d := NewDiffer().WithRedactSecrets().WithUnitAware(".*").
+		WithLabel(configLabel).WithTmpl(configDiffTmpl)
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d.Compare(a, b)
+}
+
+// isSecretField checks if a struct field is tagged `secret:"true"`.
+func isSecretField(sf reflect.StructField) bool {
+	v, ok := sf.Tag.Lookup("secret")
+	return ok && v != "false"
+}
+
+// equalQuantity checks if a and b are the same duration or byte size written differently.
+func equalQuantity(a, b string) bool {
+	da, errA := time.ParseDuration(strings.TrimSpace(a))
+	db, errB := time.ParseDuration(strings.TrimSpace(b))
+	if errA == nil && errB == nil {
+		return da == db
+	}
+	sa, okA := parseByteSize(a)
+	sb, okB := parseByteSize(b)
+	return okA && okB && math.Abs(sa-sb) < 0.5
+}
+
+// parseByteSize parses byte size such as "1024", "1.5Gi", "10MB" into number of bytes.
+func parseByteSize(s string) (float64, bool) {
+	matches := byteSizeRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	unit, ok := byteUnits[matches[2]]
+	if !ok {
+		return 0, false
+	}
+	return n * unit, true
+}
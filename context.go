@@ -0,0 +1,87 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SliceContext is the values of elements around a differing slice element, like diff -C.
+type SliceContext struct {
+	// Path is the field path of the slice.
+	Path string `json:"path"`
+
+	// Index is the index of the differing element.
+	Index int `json:"index"`
+
+	// Start is the index of the first element of A and B.
+	Start int `json:"start"`
+
+	// A and B are values of elements from Start to Index+n, and may be shorter near the end of slice.
+	A []interface{} `json:"a"`
+	B []interface{} `json:"b"`
+}
+
+// WithSliceContext makes Differ attach values of n elements before and after a differing slice element
+// to diffs inside it, see Diff.Context. It helps to spot off-by-one alignment issues.
+func (d *Differ) WithSliceContext(n int) *Differ {
+	d.contextSize = n
+	return d
+}
+
+// compareElem compares the ith elements of slice a and b, and attaches the context to diffs found inside them.
+func (d *Differ) compareElem(a, b reflect.Value, i int, fieldPath string, depth int) {
+	elemPath := concat(fieldPath, "[", strconv.Itoa(i), "]")
+	if d.contextSize <= 0 {
+		d.doCompare(a.Index(i), b.Index(i), elemPath, depth)
+		return
+	}
+	mark := len(d.elemDiffs)
+	d.elemDepth++
+	d.doCompare(a.Index(i), b.Index(i), elemPath, depth)
+	d.elemDepth--
+	if d.redacting > 0 {
+		return
+	}
+	var ctx *SliceContext
+	for _, df := range d.elemDiffs[mark:] {
+		if df.context != nil {
+			continue
+		}
+		if ctx == nil {
+			ctx = newSliceContext(a, b, i, d.contextSize, fieldPath)
+		}
+		df.context = ctx
+	}
+	// diffs of this element have the context now, enclosing elements do not need to visit them again.
+	d.elemDiffs = d.elemDiffs[:mark]
+}
+
+func newSliceContext(a, b reflect.Value, i, n int, fieldPath string) *SliceContext {
+	start := maxInt(i-n, 0)
+	ctx := &SliceContext{Path: fieldPath, Index: i, Start: start}
+	for j := start; j <= i+n && j < a.Len(); j++ {
+		ctx.A = append(ctx.A, contextValue(a.Index(j)))
+	}
+	for j := start; j <= i+n && j < b.Len(); j++ {
+		ctx.B = append(ctx.B, contextValue(b.Index(j)))
+	}
+	return ctx
+}
+
+func contextValue(v reflect.Value) interface{} {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v)
+}
+
+// isSubPath checks if fieldPath is path or a path inside it.
+func isSubPath(fieldPath, path string) bool {
+	if !strings.HasPrefix(fieldPath, path) {
+		return false
+	}
+	rest := fieldPath[len(path):]
+	return rest == "" || rest[0] == '.' || rest[0] == '['
+}
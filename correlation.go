@@ -0,0 +1,49 @@
+package sdiffer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// WithComparisonID includes a comparison ID in every diff found and every line of reports, so that diffs
+// logged asynchronously, such as by sinks, can be correlated back to the request that produced them.
+// The ID is id if it is not blank, or else a random one generated per comparison.
+func (d *Differ) WithComparisonID(id string) *Differ {
+	d.correlated = true
+	d.fixedID = id
+	return d
+}
+
+// ComparisonID returns the ID of the last comparison, see WithComparisonID.
+func (d *Differ) ComparisonID() string {
+	return d.compareID
+}
+
+// ComparisonID returns the ID of the comparison which finds the diff, see Differ.WithComparisonID.
+func (d *Diff) ComparisonID() string {
+	return d.compareID
+}
+
+// newComparisonID sets the ID of a new comparison if WithComparisonID is called.
+func (d *Differ) newComparisonID() {
+	if !d.correlated {
+		return
+	}
+	if !isStringBlank(d.fixedID) {
+		d.compareID = d.fixedID
+		return
+	}
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	d.compareID = hex.EncodeToString(buf)
+}
+
+// correlationPrefix returns the prefix of report lines of df, such as "[3f2a9c0d1e4b5a67] ".
+func correlationPrefix(df *Diff) string {
+	if isStringBlank(df.compareID) {
+		return ""
+	}
+	return concat("[", df.compareID, "] ")
+}
@@ -0,0 +1,135 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// wildcard matches all elements of a slice, array or map in paths of GetAll and Sum.
+const wildcard = "[*]"
+
+// CrossFieldRule checks a relation between fields of a and b, which are the values compared,
+// it returns values rendered in the diff, and ok is false if the relation is violated.
+type CrossFieldRule func(a, b interface{}) (va, vb interface{}, ok bool)
+
+type crossFieldRule struct {
+	name string
+	rule CrossFieldRule
+}
+
+// WithCrossField compares the field at pathA of a against the field at pathB of b,
+// instead of the same path of both, a CrossFieldDiff named "pathA ~ pathB" is recorded if they differ
+// or any of them does not exist. Paths use the same syntax as paths of diffs, see Get.
+//
+// For example:
+// differ := NewDiffer().WithCrossField("Order.Total", "Order.Paid")
+func (d *Differ) WithCrossField(pathA, pathB string) *Differ {
+	return d.WithCrossFieldRule(concat(pathA, " ~ ", pathB), func(a, b interface{}) (interface{}, interface{}, bool) {
+		va, errA := Get(a, pathA)
+		vb, errB := Get(b, pathB)
+		if errA != nil || errB != nil {
+			return iF(errA != nil, missing, va), iF(errB != nil, missing, vb), false
+		}
+		return va, vb, reflect.DeepEqual(va, vb)
+	})
+}
+
+// WithCrossFieldRule validates a relation between multiple fields of a and b after they are compared,
+// a CrossFieldDiff named name is recorded if rule is violated. Reconciliation rules can be expressed
+// with Get, GetAll and Sum, for example, A.Total should equal sum of B.Items[].Price:
+//
+//	differ := NewDiffer().WithCrossFieldRule("Order.Total = sum(Order.Items[*].Price)",
+//		func(a, b interface{}) (interface{}, interface{}, bool) {
+//			total, _ := sdiffer.Get(a, "Order.Total")
+//			sum, _ := sdiffer.Sum(b, "Order.Items[*].Price")
+//			return total, sum, total == sum
+//		})
+func (d *Differ) WithCrossFieldRule(name string, rule CrossFieldRule) *Differ {
+	d.crossRules = append(d.crossRules, &crossFieldRule{name: name, rule: rule})
+	return d
+}
+
+// checkCrossFields records diffs of violated rules set by WithCrossFieldRule.
+func (d *Differ) checkCrossFields(a, b interface{}) {
+	for _, cr := range d.crossRules {
+		if d.stopped {
+			return
+		}
+		if va, vb, ok := cr.rule(a, b); !ok {
+			d.setTypedDiff(CrossFieldDiff, cr.name, va, vb)
+		}
+	}
+}
+
+// GetAll returns values at fieldPath of value like Get, and "[*]" in fieldPath matches
+// all elements of a slice, array or map, for example:
+//
+//	prices, err := sdiffer.GetAll(order, "Order.Items[*].Price")
+func GetAll(value interface{}, fieldPath string) ([]interface{}, error) {
+	idx := strings.Index(fieldPath, wildcard)
+	if idx < 0 {
+		v, err := Get(value, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{v}, nil
+	}
+	prefix, rest := fieldPath[:idx], fieldPath[idx+len(wildcard):]
+	d := NewDiffer()
+	v, err := d.resolvePath(reflect.ValueOf(value), prefix)
+	if err != nil {
+		return nil, err
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	var paths []string
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			paths = append(paths, fmt.Sprintf("%s[%d]%s", prefix, i, rest))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			paths = append(paths, concat(prefix, d.keySegment(prefix, k), rest))
+		}
+	default:
+		return nil, fmt.Errorf("path %s: %s is not a slice, array or map", fieldPath, prefix)
+	}
+	var values []interface{}
+	for _, p := range paths {
+		vs, err := GetAll(value, p)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, vs...)
+	}
+	return values, nil
+}
+
+// Sum returns the sum of numbers at fieldPath of value, "[*]" is supported like GetAll.
+func Sum(value interface{}, fieldPath string) (float64, error) {
+	values, err := GetAll(value, fieldPath)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			sum += float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			sum += float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			sum += rv.Float()
+		default:
+			return 0, fmt.Errorf("path %s: %v is not a number", fieldPath, v)
+		}
+	}
+	return sum, nil
+}
@@ -0,0 +1,201 @@
+package sdiffer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const csvKeySep = "|"
+
+// CSVCellDiff is a diff of a cell found by CompareCSV.
+// Rows are 1-based indices of records excluding the header, and columns are 1-based indices in the header.
+type CSVCellDiff struct {
+	Key    string
+	Column string
+	RowA   int
+	RowB   int
+	ColA   int
+	ColB   int
+	A      interface{}
+	B      interface{}
+}
+
+func (cd *CSVCellDiff) String() string {
+	return fmt.Sprintf(`Row: "%s", Column: "%s", A(%d,%d): %v, B(%d,%d): %v`,
+		cd.Key, cd.Column, cd.RowA, cd.ColA, cd.A, cd.RowB, cd.ColB, cd.B)
+}
+
+// CSVResult is the result of CompareCSV.
+type CSVResult struct {
+	// OnlyInA and OnlyInB are keys of rows only in a or b.
+	OnlyInA []string
+	OnlyInB []string
+
+	// ColumnsOnlyInA and ColumnsOnlyInB are columns only in the header of a or b,
+	// they are not compared.
+	ColumnsOnlyInA []string
+	ColumnsOnlyInB []string
+
+	// DuplicateInA and DuplicateInB are keys of rows repeating the key of an earlier row in a or b,
+	// one for each repeating row. Only the first row of a key is compared.
+	DuplicateInA []string
+	DuplicateInB []string
+
+	// Diffs are sorted by row key and column.
+	Diffs []*CSVCellDiff
+}
+
+// HasDiffs checks if any diff is found, duplicate keys are treated as diffs.
+func (cr *CSVResult) HasDiffs() bool {
+	return len(cr.OnlyInA) > 0 || len(cr.OnlyInB) > 0 || len(cr.DuplicateInA) > 0 || len(cr.DuplicateInB) > 0 ||
+		len(cr.ColumnsOnlyInA) > 0 || len(cr.ColumnsOnlyInB) > 0 || len(cr.Diffs) > 0
+}
+
+// OptCSVKeys set the key columns to match rows in CompareCSV, multiple key columns are joined by "|".
+// Rows are matched by their indices if no key column is set.
+func OptCSVKeys(columns ...string) Option {
+	return func(d *Differ) {
+		d.csvKeys = columns
+	}
+}
+
+type csvTable struct {
+	header  []string
+	columns map[string]int
+	rows    []map[string]string
+}
+
+// CompareCSV compares two CSV documents with headers, rows are matched by key columns, see OptCSVKeys.
+// Rows repeating the key of an earlier row are reported in DuplicateInA and DuplicateInB, and not compared.
+// Cells are compared under the path "$[<column>]", so that tolerances and trims can be set by column.
+//
+// For example:
+// CompareCSV(a, b, OptCSVKeys("id"), OptTolerance(`\[price\]$`, 0.01), OptTrimSpace(`\[name\]$`))
+func CompareCSV(a, b io.Reader, opts ...Option) (*CSVResult, error) {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	ta, err := readCSV(a)
+	if err != nil {
+		return nil, fmt.Errorf("read csv a: %w", err)
+	}
+	tb, err := readCSV(b)
+	if err != nil {
+		return nil, fmt.Errorf("read csv b: %w", err)
+	}
+
+	cr := &CSVResult{}
+	var shared []string
+	for _, col := range ta.header {
+		if _, ok := tb.columns[col]; ok {
+			shared = append(shared, col)
+		} else {
+			cr.ColumnsOnlyInA = append(cr.ColumnsOnlyInA, col)
+		}
+	}
+	for _, col := range tb.header {
+		if _, ok := ta.columns[col]; !ok {
+			cr.ColumnsOnlyInB = append(cr.ColumnsOnlyInB, col)
+		}
+	}
+
+	keyOf := func(row map[string]string, idx int) string {
+		if len(d.csvKeys) == 0 {
+			return strconv.Itoa(idx + 1)
+		}
+		values := make([]string, 0, len(d.csvKeys))
+		for _, k := range d.csvKeys {
+			values = append(values, row[k])
+		}
+		return strings.Join(values, csvKeySep)
+	}
+	rowsB := make(map[string]int, len(tb.rows))
+	for i, row := range tb.rows {
+		if key := keyOf(row, i); !containsKey(rowsB, key) {
+			rowsB[key] = i
+		} else {
+			cr.DuplicateInB = append(cr.DuplicateInB, key)
+		}
+	}
+	matched := make(map[string]struct{}, len(ta.rows))
+	for i, row := range ta.rows {
+		key := keyOf(row, i)
+		if _, ok := matched[key]; ok {
+			cr.DuplicateInA = append(cr.DuplicateInA, key)
+			continue
+		}
+		matched[key] = struct{}{}
+		j, ok := rowsB[key]
+		if !ok {
+			cr.OnlyInA = append(cr.OnlyInA, key)
+			continue
+		}
+		ra, rb := make(map[string]string, len(shared)), make(map[string]string, len(shared))
+		for _, col := range shared {
+			ra[col], rb[col] = row[col], tb.rows[j][col]
+		}
+		d.resetResult()
+		for _, df := range d.Compare(ra, rb).Result().Diffs {
+			col := unquoteKey(strings.TrimSuffix(strings.TrimPrefix(df.name, initTypeName+"["), "]"))
+			cr.Diffs = append(cr.Diffs, &CSVCellDiff{
+				Key:    key,
+				Column: col,
+				RowA:   i + 1,
+				RowB:   j + 1,
+				ColA:   ta.columns[col] + 1,
+				ColB:   tb.columns[col] + 1,
+				A:      df.Va(),
+				B:      df.Vb(),
+			})
+		}
+	}
+	for i, row := range tb.rows {
+		if key := keyOf(row, i); rowsB[key] == i {
+			if _, ok := matched[key]; !ok {
+				cr.OnlyInB = append(cr.OnlyInB, key)
+			}
+		}
+	}
+	sort.SliceStable(cr.Diffs, func(i, j int) bool {
+		if cr.Diffs[i].RowA != cr.Diffs[j].RowA {
+			return cr.Diffs[i].RowA < cr.Diffs[j].RowA
+		}
+		return cr.Diffs[i].ColA < cr.Diffs[j].ColA
+	})
+	return cr, nil
+}
+
+func containsKey(m map[string]int, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func readCSV(r io.Reader) (*csvTable, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	t := &csvTable{columns: make(map[string]int)}
+	if len(records) == 0 {
+		return t, nil
+	}
+	t.header = records[0]
+	for i, col := range t.header {
+		t.columns[col] = i
+	}
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(t.header))
+		for i, col := range t.header {
+			if i < len(record) {
+				row[col] = record[i]
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+package sdiffer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type dateFormat struct {
+	fieldRegexp *regexp.Regexp
+	layouts     []string
+}
+
+// WithDateFormat parses strings whose path matches fieldPath with layouts and compares them as instants,
+// so that dates written in different formats such as "2024-01-02" and "02 Jan 2024" are treated as equal.
+// Strings which cannot be parsed with any of layouts are compared as they are.
+//
+// For example:
+// differ := NewDiffer().WithDateFormat(`CreatedAt$`, "2006-01-02", "02 Jan 2006", time.RFC3339)
+func (d *Differ) WithDateFormat(fieldPath string, layouts ...string) *Differ {
+	if len(layouts) == 0 {
+		panic("date format requires at least one layout")
+	}
+	d.dateFormats = append(d.dateFormats, &dateFormat{regexp.MustCompile(fieldPath), layouts})
+	return d
+}
+
+// parse parses s with the first layout that fits.
+func (df *dateFormat) parse(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range df.layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareDate compares a and b as instants if they are dates in formats set by WithDateFormat,
+// it returns false if a and b are not both parsed.
+func (d *Differ) compareDate(a, b string, fieldPath string) (equal, ok bool) {
+	for _, df := range d.dateFormats {
+		if !df.fieldRegexp.MatchString(fieldPath) {
+			continue
+		}
+		ta, okA := df.parse(a)
+		tb, okB := df.parse(b)
+		if okA && okB {
+			return ta.Equal(tb), true
+		}
+		return false, false
+	}
+	return false, false
+}
@@ -0,0 +1,68 @@
+package sdiffer
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+)
+
+type deltaThreshold struct {
+	fieldRegexp *regexp.Regexp
+	abs         float64
+	percent     float64
+}
+
+// WithDeltaThreshold reports numeric diffs of fields whose path matches fieldPath only when the absolute
+// delta of them is greater than absDelta, or the absolute percentage change is greater than percent,
+// non-positive thresholds are not checked. It suits reconciling metrics where absolute equality is the wrong bar.
+//
+// For example, report diffs of Amount only when they differ by more than 100 or 5%:
+// differ := NewDiffer().WithDeltaThreshold(`\.Amount$`, 100, 5)
+func (d *Differ) WithDeltaThreshold(fieldPath string, absDelta, percent float64) *Differ {
+	d.deltas = append(d.deltas, &deltaThreshold{regexp.MustCompile(fieldPath), absDelta, percent})
+	return d
+}
+
+// Delta returns the delta B - A and the percentage change of it relative to A if A and B are numbers,
+// percent is NaN if A is 0.
+func (d *Diff) Delta() (delta, percent float64, ok bool) {
+	va, vb := reflect.ValueOf(d.Va()), reflect.ValueOf(d.Vb())
+	if d.dt != ElemDiff || !isNumberKind(va) || !isNumberKind(vb) {
+		return 0, 0, false
+	}
+	fa, _ := toFloat(va)
+	fb, _ := toFloat(vb)
+	return numberDelta(fa, fb)
+}
+
+func numberDelta(fa, fb float64) (delta, percent float64, ok bool) {
+	delta = fb - fa
+	if fa == 0 {
+		return delta, math.NaN(), true
+	}
+	return delta, delta / math.Abs(fa) * 100, true
+}
+
+// belowDeltaThreshold checks if numbers va and vb of fieldPath differ within the threshold set by WithDeltaThreshold.
+func (d *Differ) belowDeltaThreshold(fieldPath string, va, vb interface{}) bool {
+	for _, t := range d.deltas {
+		if !t.fieldRegexp.MatchString(fieldPath) {
+			continue
+		}
+		ra, rb := reflect.ValueOf(va), reflect.ValueOf(vb)
+		if !isNumberKind(ra) || !isNumberKind(rb) {
+			return false
+		}
+		fa, _ := toFloat(ra)
+		fb, _ := toFloat(rb)
+		delta, percent, _ := numberDelta(fa, fb)
+		if t.abs > 0 && math.Abs(delta) > t.abs {
+			return false
+		}
+		if t.percent > 0 && (math.IsNaN(percent) || math.Abs(percent) > t.percent) {
+			return false
+		}
+		return t.abs > 0 || t.percent > 0
+	}
+	return false
+}
@@ -1,42 +1,167 @@
 package sdiffer
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
 	"strings"
 )
 
 const defaultDiffTmpl = `Field: "%s", A: %v, B: %v`
 
-type diff struct {
+// Diff is a difference found by Differ.
+type Diff struct {
 	name string
 	va   interface{}
 	vb   interface{}
+	dt   DiffType
+	posA *Pos
+	posB *Pos
+
+	// pa and pb hold the values of a and b instead of va and vb if they are primitives, see primitive.
+	pa primitive
+	pb primitive
+
+	// count and samples record the diffs collapsed into this one, see Differ.WithAggregation.
+	count   int
+	samples []string
+
+	// byComparator is true if the diff is found by a Comparator.
+	byComparator bool
+
+	// sourceA and sourceB are metadata of where A and B come from, see Differ.WithSourceInfo.
+	sourceA map[string]interface{}
+	sourceB map[string]interface{}
+
+	// compareID is the ID of the comparison which finds the diff, see Differ.WithComparisonID.
+	compareID string
+
+	// context is the elements around the slice element this diff is inside, see Differ.WithSliceContext.
+	context *SliceContext
 }
 
-func newDiff(name string, a, b interface{}) *diff {
-	return &diff{
-		name: name,
-		va:   a,
-		vb:   b,
+func newDiff(name string, a, b interface{}) *Diff {
+	return &Diff{
+		name:  name,
+		va:    a,
+		vb:    b,
+		dt:    ElemDiff,
+		count: 1,
 	}
 }
 
-func (d *diff) Name() string {
+func (d *Diff) Name() string {
 	return d.name
 }
 
-func (d *diff) Va() interface{} {
+func (d *Diff) Va() interface{} {
+	if d.pa.typ != nil {
+		return d.pa.value()
+	}
 	return d.va
 }
 
-func (d *diff) Vb() interface{} {
+func (d *Diff) Vb() interface{} {
+	if d.pb.typ != nil {
+		return d.pb.value()
+	}
 	return d.vb
 }
 
+// Path returns the field path of Diff, it is the same as Name.
+func (d *Diff) Path() string {
+	return d.name
+}
+
+// A returns the value of a, it is the same as Va.
+func (d *Diff) A() interface{} {
+	return d.Va()
+}
+
+// B returns the value of b, it is the same as Vb.
+func (d *Diff) B() interface{} {
+	return d.Vb()
+}
+
+// setValues replaces the values of a and b with va and vb.
+func (d *Diff) setValues(va, vb interface{}) {
+	d.va, d.vb = va, vb
+	d.pa, d.pb = primitive{}, primitive{}
+}
+
+// Type returns the DiffType of Diff.
+func (d *Diff) Type() DiffType {
+	return d.dt
+}
+
+// PosA returns the position of A in the source document, see Differ.CompareJSON.
+func (d *Diff) PosA() (Pos, bool) {
+	if d.posA == nil {
+		return Pos{}, false
+	}
+	return *d.posA, true
+}
+
+// PosB returns the position of B in the source document, see Differ.CompareJSON.
+func (d *Diff) PosB() (Pos, bool) {
+	if d.posB == nil {
+		return Pos{}, false
+	}
+	return *d.posB, true
+}
+
+// Count returns the number of diffs collapsed into this one, see Differ.WithAggregation.
+func (d *Diff) Count() int {
+	return d.count
+}
+
+// Samples returns slice indices of some diffs collapsed into this one, see Differ.WithAggregation.
+func (d *Diff) Samples() []string {
+	return d.samples
+}
+
+// ByComparator checks if the diff is found by a Comparator.
+func (d *Diff) ByComparator() bool {
+	return d.byComparator
+}
+
+// Context returns the elements around the slice element this diff is inside, see Differ.WithSliceContext.
+func (d *Diff) Context() (*SliceContext, bool) {
+	return d.context, d.context != nil
+}
+
+// MarshalJSON marshals Diff as {"path": ..., "type": ..., "a": ..., "b": ...},
+// with "context" if the slice context is attached, "byComparator" if it is found by a Comparator,
+// "sourceA" and "sourceB" if the source info is attached, "comparisonId" if the comparison ID is included,
+// and "delta" and "percent" if A and B are numbers, see Delta.
+func (d *Diff) MarshalJSON() ([]byte, error) {
+	var delta, percent *float64
+	if dv, pv, ok := d.Delta(); ok {
+		delta = &dv
+		if !math.IsNaN(pv) {
+			percent = &pv
+		}
+	}
+	return json.Marshal(&struct {
+		Path         string                 `json:"path"`
+		Type         DiffType               `json:"type"`
+		A            interface{}            `json:"a"`
+		B            interface{}            `json:"b"`
+		Context      *SliceContext          `json:"context,omitempty"`
+		ByComparator bool                   `json:"byComparator,omitempty"`
+		SourceA      map[string]interface{} `json:"sourceA,omitempty"`
+		SourceB      map[string]interface{} `json:"sourceB,omitempty"`
+		ComparisonID string                 `json:"comparisonId,omitempty"`
+		Delta        *float64               `json:"delta,omitempty"`
+		Percent      *float64               `json:"percent,omitempty"`
+	}{d.name, d.dt, d.Va(), d.Vb(), d.context, d.byComparator, d.sourceA, d.sourceB, d.compareID, delta, percent})
+}
+
 // Tag generate a short tag of the diff name.
 // For example:
 // Person.Schools[0].Buildings[2].Name => Person.Schools.Buildings.Name
-func (d *diff) Tag() (tag string) {
+func (d *Diff) Tag() (tag string) {
 	cut := func(str string) string {
 		idx := strings.Index(str, "[")
 		if idx > 0 {
@@ -44,7 +169,7 @@ func (d *diff) Tag() (tag string) {
 		}
 		return str
 	}
-	words := strings.Split(d.name, ".")
+	words := strings.Split(stripKeys(d.name), ".")
 	for _, word := range words {
 		if strings.HasSuffix(word, "]") {
 			word = cut(word)
@@ -54,11 +179,59 @@ func (d *diff) Tag() (tag string) {
 	return
 }
 
-func (d *diff) String(tmpl ...string) string {
+// stripKeys replaces bracket segments of fieldPath with [], so that quoted map keys are not split.
+func stripKeys(fieldPath string) string {
+	builder := &strings.Builder{}
+	for i := 0; i < len(fieldPath); i++ {
+		if fieldPath[i] == '[' {
+			if end := closingBracket(fieldPath[i:]); end > 0 {
+				builder.WriteString("[]")
+				i += end
+				continue
+			}
+		}
+		builder.WriteByte(fieldPath[i])
+	}
+	return builder.String()
+}
+
+func (d *Diff) String(tmpl ...string) string {
 	for _, t := range tmpl {
 		if !isStringBlank(t) {
-			return fmt.Sprintf(t, d.name, d.va, d.vb)
+			return fmt.Sprintf(t, d.name, d.Va(), d.Vb())
+		}
+	}
+	return fmt.Sprintf(defaultDiffTmpl, d.name, d.Va(), d.Vb())
+}
+
+// ValueAs sets the value v into the variable ptr points to, and returns false
+// if v can not be assigned or converted to it.
+//
+// For example:
+// var age int
+// ok := ValueAs(df.A(), &age)
+func ValueAs(v interface{}, ptr interface{}) bool {
+	pv := reflect.ValueOf(ptr)
+	if pv.Kind() != reflect.Ptr || pv.IsNil() {
+		return false
+	}
+	target := pv.Elem()
+	if v == nil {
+		switch target.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+			target.Set(reflect.Zero(target.Type()))
+			return true
 		}
+		return false
+	}
+	vv := reflect.ValueOf(v)
+	switch {
+	case vv.Type().AssignableTo(target.Type()):
+		target.Set(vv)
+	case vv.Type().ConvertibleTo(target.Type()) && vv.Kind() != reflect.String && target.Kind() != reflect.String:
+		target.Set(vv.Convert(target.Type()))
+	default:
+		return false
 	}
-	return fmt.Sprintf(defaultDiffTmpl, d.name, d.va, d.vb)
+	return true
 }
@@ -2,10 +2,13 @@ package sdiffer
 
 import (
 	"fmt"
+	"io"
 	. "reflect"
 	"regexp"
+	"sort"
 	"strconv"
-	"strings"
+	"text/template"
+	"time"
 )
 
 type diffMode int
@@ -20,7 +23,10 @@ const (
 	initTypeName        = "$"
 	null                = "<nil>"
 	notNull             = "<not nil>"
+	missing             = "<missing>"
+	maxMissingValueLen  = 64
 	useComparatorSuffix = ".$[customized]"
+	lengthSuffix        = "[Length]"
 	defaultDepthLimit   = 30
 )
 
@@ -32,35 +38,176 @@ const (
 // Attention:
 // Differ may cause panic when you call Compare.
 type Differ struct {
-	diffs       map[string]*diff
-	ignores     []*regexp.Regexp
-	includes    []*regexp.Regexp
-	trimSpaces  []*regexp.Regexp
-	trimTags    []*trimTag
-	comparators []Comparator
-	sorters     []Sorter
-	maxDepth    int
-	diffTmpl    string
-	bff         *bufferF
+	diffs         map[string]*Diff
+	ignores       []*regexp.Regexp
+	includes      []*regexp.Regexp
+	trimSpaces    []*regexp.Regexp
+	trimTags      []*trimTag
+	comparators   []Comparator
+	sorters       []Sorter
+	mapSorters    []MapSorter
+	redacts       []*regexp.Regexp
+	unitAwares    []*regexp.Regexp
+	normalizers   []UnitNormalizer
+	dateFormats   []*dateFormat
+	mustEquals    []*regexp.Regexp
+	expecteds     []*expectedDiff
+	crossRules    []*crossFieldRule
+	suppressions  []*suppression
+	scopedIgnores []*scopedIgnore
+	fullMatch     bool
+	foldCase      bool
+	reportEqual   bool
+	contextSize   int
+	shifts        []*regexp.Regexp
+	pathLabels    map[string]string
+	nullAsZeros   []*regexp.Regexp
+	keyFormats    []*keyStringer
+	kindHandlers  map[Kind]KindHandler
+	typeComparers map[Type]Value
+	methods       []*methodRule
+	unwraps       map[Type]Unwrapper
+	namedUnwraps  map[string]Unwrapper
+	textuals      []*regexp.Regexp
+	errorRules    []*errorRule
+	syncMaps      []*regexp.Regexp
+	skipTypes     []Type
+	compareInfra  bool
+	tolerances    []*tolerance
+	lenTolerances []*lengthTolerance
+	deltas        []*deltaThreshold
+	keyMatchers   []*keyMatcher
+	timeWindows   []*timeWindow
+	postHooks     []func(result *Result)
+	csvKeys       []string
+	migrations    []func(old interface{}) interface{}
+	maxDepth      int
+	maxDiffs      int
+	diffTmpl      string
+	tmpl          *template.Template
+	reportTmpl    *template.Template
+	pathTmpls     []*pathTmpl
+	sectioned     bool
+	sections      []*namedSection
+	pathStyle     *pathStyle
+	mapHashing    *mapHashing
+	label         string
+	sourceA       map[string]interface{}
+	sourceB       map[string]interface{}
+
+	// redactSecrets redacts values of fields tagged `secret:"true"`,
+	// redacting is positive while comparing inside such fields.
+	redactSecrets bool
+	redacting     int
+
+	// aggregated collapses identical diffs, see WithAggregation.
+	aggregated bool
+	aggGroups  map[string]*Diff
+
+	// sinks receive results of comparisons, see WithSink.
+	sinks   []Sink
+	sinkErr error
+
+	// stream is the writer report streamed into, see WriteReport.
+	stream        io.Writer
+	streamErr     error
+	streamField   string
+	flushPerField bool
+
+	// visited records leaf paths compared when recordVisited is true.
+	recordVisited bool
+	visited       map[string]struct{}
+
+	// expandNil compares the non-nil side against the zero value for nil diffs.
+	expandNil bool
+
+	// comparatorSuffix appends ".$[customized]" to paths compared by Comparators,
+	// byComparator is true while comparing with a Comparator.
+	comparatorSuffix bool
+	byComparator     bool
+
+	// tracer starts spans of comparisons, span is the current one, see WithTracer.
+	tracer     Tracer
+	tracePaths []*regexp.Regexp
+	span       Span
+	nodeCount  int
+
+	// ruleHits counts matches of rules by kind and index, see RuleStats.
+	ruleHits map[ruleKey]int
+
+	// robust recovers panics when comparing a node, see WithRecover.
+	// sorterRecover recovers panics of Sorter.Less, see WithSorterRecover.
+	robust        bool
+	sorterRecover bool
+
+	// classified appends classifications of changes to report lines, see WithChangeClassification.
+	classified bool
+
+	// positioned appends positions of the first differing runes of strings to report lines, see WithStringPosition.
+	positioned bool
+
+	// correlated includes the ID of comparisons, which is fixedID or generated, in diffs, see WithComparisonID.
+	correlated bool
+	fixedID    string
+	compareID  string
+
+	// maxReportBytes limits the size of reports, severity decides diffs to keep, see WithMaxReportBytes.
+	maxReportBytes int
+	severity       SeverityFunc
+
+	// memo records pairs of pointers found equal when memoized is true, see WithMemoization.
+	memoized bool
+	memo     map[pointerPair]struct{}
+
+	// quiet mode stops comparison at the first diff without collecting it.
+	quiet     bool
+	stopped   bool
+	diffCount int
+
+	// violated is true if any field set by WithMustEqual differs.
+	violated bool
+
+	// expectedDiffs are diffs declared by WithExpectedDiff.
+	expectedDiffs map[string]*Diff
+
+	// equals are fields found equal, recorded when reportEqual is true.
+	equals map[string]*Diff
+
+	// timings is the time spent on the last comparison of CompareLazy.
+	timings *Timings
+
+	// elemDiffs are diffs added while comparing slice elements, elemDepth is the number of
+	// nested elements being compared, they are tracked only if WithSliceContext is called.
+	elemDiffs []*Diff
+	elemDepth int
+
+	// entryOwner is the path of the container whose entry diff is being recorded, see setEntryDiff.
+	entryOwner string
+
+	// typeChanges is true while comparing decoded documents, where values of interfaces
+	// changing their dynamic types are recorded as ElemDiffs instead of panicking, see compareDecoded.
+	typeChanges bool
+
+	// rootType is the type of values compared last time.
+	rootType Type
+
+	// errs are errors occurred when comparing with Comparators.
+	errs []*CompareError
 }
 
 func NewDiffer() *Differ {
 	return &Differ{
-		diffs:    make(map[string]*diff, 16),
-		bff:      newBufferF(),
+		diffs:    make(map[string]*Diff, 16),
 		maxDepth: defaultDepthLimit,
 	}
 }
 
 func (d *Differ) String() string {
-	for _, df := range d.diffs {
-		d.bff.sprintf("%s\n", df.String(d.diffTmpl))
-	}
-	return d.bff.String()
+	return d.Render("")
 }
 
-func (d *Differ) Diffs() []*diff {
-	dfs := make([]*diff, 0, len(d.diffs))
+func (d *Differ) Diffs() []*Diff {
+	dfs := make([]*Diff, 0, len(d.diffs))
 	for _, df := range d.diffs {
 		dfs = append(dfs, df)
 	}
@@ -74,6 +221,46 @@ func (d *Differ) WithMaxDepth(depth int) *Differ {
 	return d
 }
 
+// WithMaxDiffs set the max number of diffs to collect.
+// Differ stops comparing once the limit is reached, 0 means no limit.
+func (d *Differ) WithMaxDiffs(n int) *Differ {
+	d.maxDiffs = n
+	return d
+}
+
+// WithVisitedPaths makes Differ record every leaf path compared, see VisitedPaths.
+func (d *Differ) WithVisitedPaths() *Differ {
+	d.recordVisited = true
+	return d
+}
+
+// VisitedPaths returns every leaf path compared sorted by path, WithVisitedPaths must be called
+// before Compare, or else nil will be returned.
+// It helps to check that ignores and includes are not silently matching nothing,
+// and that new struct fields are covered by comparison rules.
+func (d *Differ) VisitedPaths() []string {
+	if len(d.visited) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(d.visited))
+	for path := range d.visited {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// WithLabel set a label for Differ, which will be printed as the head of String.
+func (d *Differ) WithLabel(label string) *Differ {
+	d.label = label
+	return d
+}
+
+// Label returns the label of Differ.
+func (d *Differ) Label() string {
+	return d.label
+}
+
 // WithTmpl set diff tmpl for Differ.
 // Tmpl must contains exactly 3 placeholders, such as:
 // `Field: "%s", A: %v, B: %v`
@@ -82,29 +269,108 @@ func (d *Differ) WithTmpl(tmpl string) *Differ {
 	return d
 }
 
+// WithTemplate set a text/template for rendering each diff, which takes precedence over WithTmpl.
+// Available fields are {{.Path}}, {{.A}}, {{.B}} and {{.Type}}, such as:
+// `{{.Type}} diff on {{.Path}}: {{.A}} => {{.B}}`
+func (d *Differ) WithTemplate(tmpl string) *Differ {
+	d.tmpl = template.Must(template.New("diff").Parse(tmpl))
+	return d
+}
+
+// WithReportTemplate set a text/template for rendering the whole report of String.
+// Available fields are {{.Label}}, {{.Count}} and {{.Diffs}}, each diff of {{.Diffs}}
+// has the fields of WithTemplate and {{.Text}} which is the diff rendered by Differ, such as:
+// `{{.Label}}: {{.Count}} diffs{{range .Diffs}}
+// - {{.Text}}{{end}}`
+func (d *Differ) WithReportTemplate(tmpl string) *Differ {
+	d.reportTmpl = template.Must(template.New("report").Parse(tmpl))
+	return d
+}
+
+// WithPathTemplate set a text/template for rendering diffs whose path matches fieldPath,
+// which takes precedence over WithTemplate, the first matched one will be used.
+// See WithTemplate for available fields.
+func (d *Differ) WithPathTemplate(fieldPath string, tmpl string) *Differ {
+	d.pathTmpls = append(d.pathTmpls, newPathTmpl(fieldPath, tmpl))
+	return d
+}
+
+// WithSections makes String group diffs into sections by top-level field,
+// diffs are sorted by path in each section.
+func (d *Differ) WithSections() *Differ {
+	d.sectioned = true
+	return d
+}
+
 // Ignore set fields that do not need to be compared.
 // Ignore will not work after Includes is called.
+// Patterns match any part of paths, see WithFullMatch and WithCaseInsensitive to change it.
 func (d *Differ) Ignore(regexps ...string) *Differ {
 	if len(d.includes) > 0 {
 		return d
 	}
 	d.ignores = make([]*regexp.Regexp, 0, len(regexps))
 	for _, expr := range regexps {
-		d.ignores = append(d.ignores, regexp.MustCompile(expr))
+		d.ignores = append(d.ignores, regexp.MustCompile(d.pattern(expr)))
 	}
 	return d
 }
 
+// IgnoreE works like Ignore, but returns an error instead of panicking
+// when any of regexps is invalid, and Differ will not be changed in that case.
+func (d *Differ) IgnoreE(regexps ...string) (*Differ, error) {
+	if len(d.includes) > 0 {
+		return d, nil
+	}
+	ignores, err := d.compilePatterns(regexps)
+	if err != nil {
+		return d, err
+	}
+	d.ignores = ignores
+	return d, nil
+}
+
 // Includes set fields that need to be compared.
 // Ignore will not work after Includes is called.
+// Patterns match any part of paths, see WithFullMatch and WithCaseInsensitive to change it.
 func (d *Differ) Includes(regexps ...string) *Differ {
 	d.includes = make([]*regexp.Regexp, 0, len(regexps))
 	for _, expr := range regexps {
-		d.includes = append(d.includes, regexp.MustCompile(expr))
+		d.includes = append(d.includes, regexp.MustCompile(d.pattern(expr)))
 	}
 	return d
 }
 
+// IncludesE works like Includes, but returns an error instead of panicking
+// when any of regexps is invalid, and Differ will not be changed in that case.
+func (d *Differ) IncludesE(regexps ...string) (*Differ, error) {
+	includes, err := d.compilePatterns(regexps)
+	if err != nil {
+		return d, err
+	}
+	d.includes = includes
+	return d, nil
+}
+
+// Validate checks the configuration of Differ, including:
+// tmpl must contain exactly 3 placeholders, max depth must be positive.
+// Patterns are compiled when they are set, use IgnoreE and IncludesE
+// to get errors of them instead of panics.
+func (d *Differ) Validate() error {
+	if d.maxDepth <= 0 {
+		return fmt.Errorf("max depth must be positive, got %d", d.maxDepth)
+	}
+	if !isStringBlank(d.diffTmpl) {
+		if n := countPlaceholders(d.diffTmpl); n != 3 {
+			return fmt.Errorf("tmpl must contain exactly 3 placeholders, got %d: %q", n, d.diffTmpl)
+		}
+	}
+	if d.maxDiffs < 0 {
+		return fmt.Errorf("max diffs must not be negative, got %d", d.maxDiffs)
+	}
+	return nil
+}
+
 // WithComparator specify some fields to compare with a customized Comparator.
 func (d *Differ) WithComparator(c Comparator) *Differ {
 	d.comparators = append(d.comparators, c)
@@ -117,13 +383,15 @@ func (d *Differ) WithSorter(s Sorter) *Differ {
 	return d
 }
 
-// WithTrim trim string before comparison.
+// WithTrim trim string before comparison, it also applies to []byte values, values implementing
+// fmt.Stringer, and string keys of maps whose path matches fieldPath.
 func (d *Differ) WithTrim(fieldPath string, cutset string) *Differ {
 	d.trimTags = append(d.trimTags, newTrimTag(fieldPath, cutset))
 	return d
 }
 
-// WithTrimSpace trim space before comparison.
+// WithTrimSpace trim space before comparison, it also applies to []byte values, values implementing
+// fmt.Stringer, and string keys of maps whose path matches any of fieldPaths.
 func (d *Differ) WithTrimSpace(fieldPaths ...string) *Differ {
 	for _, exp := range fieldPaths {
 		d.trimSpaces = append(d.trimSpaces, regexp.MustCompile(exp))
@@ -131,14 +399,89 @@ func (d *Differ) WithTrimSpace(fieldPaths ...string) *Differ {
 	return d
 }
 
-// FindDiff find diff with name.
-func (d *Differ) FindDiff(fieldName string) (df *diff, ok bool) {
-	df, ok = d.diffs[fieldName]
-	return
+// WithRedact redact values of diffs whose path matches any of fieldPaths.
+func (d *Differ) WithRedact(fieldPaths ...string) *Differ {
+	for _, exp := range fieldPaths {
+		d.redacts = append(d.redacts, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// WithRedactSecrets redact values of diffs inside struct fields tagged `secret:"true"`.
+func (d *Differ) WithRedactSecrets() *Differ {
+	d.redactSecrets = true
+	return d
+}
+
+// WithUnitAware treat strings as equal if they are the same duration or byte size
+// written differently, such as "5s" and "5000ms", "1Gi" and "1073741824".
+func (d *Differ) WithUnitAware(fieldPaths ...string) *Differ {
+	for _, exp := range fieldPaths {
+		d.unitAwares = append(d.unitAwares, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// WithKeyStringer render keys of maps whose path matches mapPath with fn,
+// by default keys are rendered in a canonical form, see formatKey.
+func (d *Differ) WithKeyStringer(mapPath string, fn func(key interface{}) string) *Differ {
+	d.keyFormats = append(d.keyFormats, &keyStringer{regexp.MustCompile(mapPath), fn})
+	return d
+}
+
+// WithNilExpansion makes Differ compare the non-nil side against the zero value when only one of
+// two pointers, slices or maps is nil, in addition to the nil diff, so that the report shows what
+// data is present on the populated side. Pointers to primitives are not expanded, only the nil diff is reported.
+func (d *Differ) WithNilExpansion() *Differ {
+	d.expandNil = true
+	return d
+}
+
+// WithRecover makes Differ recover any panic when comparing a node, and record an
+// UncomparableDiff with the panic message as both A and B for the path of the node,
+// then go on comparing the other nodes.
+// It helps to compare arbitrary payloads in which a single weird value should not kill the whole comparison.
+func (d *Differ) WithRecover() *Differ {
+	d.robust = true
+	return d
+}
+
+// WithSorterRecover makes Differ recover panics of Sorter.Less, and record a SorterDiff with
+// the path of the slice, the elements being compared and the panic message as both A and B,
+// then compare the slice unsorted.
+// Without it, a panic of Sorter.Less is re-panicked as a *SorterError carrying the same context.
+func (d *Differ) WithSorterRecover() *Differ {
+	d.sorterRecover = true
+	return d
+}
+
+// WithMigration upgrades a with fn before comparison, so that objects of an older schema version
+// can be compared against the new shape, and only the differences remaining after migration
+// are reported. Migrations are applied in the order they are set.
+//
+// Migrations run only in Compare and comparisons built on it, such as CompareLazy and CompareAll.
+// CompareAt navigates the original values and does not run them.
+func (d *Differ) WithMigration(fn func(old interface{}) interface{}) *Differ {
+	d.migrations = append(d.migrations, fn)
+	return d
+}
+
+// FindDiff find diff with name, which is the canonical path or the path styled by WithRootName
+// and WithPathSeparator.
+func (d *Differ) FindDiff(fieldName string) (df *Diff, ok bool) {
+	if df, ok = d.diffs[fieldName]; ok || d.pathStyle == nil {
+		return
+	}
+	for _, df = range d.diffs {
+		if df.name == fieldName {
+			return df, true
+		}
+	}
+	return nil, false
 }
 
 // FindDiffFuzzily find diff with regexp.
-func (d *Differ) FindDiffFuzzily(expr string) (dfs []*diff) {
+func (d *Differ) FindDiffFuzzily(expr string) (dfs []*Diff) {
 	if r, err := regexp.Compile(expr); err == nil {
 		for name, df := range d.diffs {
 			if r.MatchString(name) {
@@ -149,6 +492,44 @@ func (d *Differ) FindDiffFuzzily(expr string) (dfs []*diff) {
 	return
 }
 
+// HasDiffs checks if any diff is found.
+func (d *Differ) HasDiffs() bool {
+	return d.diffCount > 0
+}
+
+// HasDiffsMatching checks if any diff whose name matches expr is found,
+// it panics if expr is invalid, see HasDiffsMatchingE.
+func (d *Differ) HasDiffsMatching(expr string) bool {
+	return d.hasDiffsMatching(regexp.MustCompile(expr))
+}
+
+// HasDiffsMatchingE works like HasDiffsMatching, but returns an error instead of panicking
+// when expr is invalid.
+func (d *Differ) HasDiffsMatchingE(expr string) (bool, error) {
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return false, err
+	}
+	return d.hasDiffsMatching(r), nil
+}
+
+func (d *Differ) hasDiffsMatching(r *regexp.Regexp) bool {
+	for name := range d.diffs {
+		if r.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MustBeEqual panics with the diff report if any diff is found.
+func (d *Differ) MustBeEqual() *Differ {
+	if d.HasDiffs() {
+		panic("not equal:\n" + d.String())
+	}
+	return d
+}
+
 func (d *Differ) Reset() *Differ {
 	d.includes = make([]*regexp.Regexp, 0, len(d.includes))
 	d.ignores = make([]*regexp.Regexp, 0, len(d.ignores))
@@ -156,57 +537,226 @@ func (d *Differ) Reset() *Differ {
 	d.trimTags = make([]*trimTag, 0, len(d.trimTags))
 	d.comparators = make([]Comparator, 0, len(d.comparators))
 	d.sorters = make([]Sorter, 0, len(d.sorters))
-	d.diffs = make(map[string]*diff, len(d.diffs))
-	d.bff = newBufferF()
+	d.mapSorters = nil
+	d.redacts = make([]*regexp.Regexp, 0, len(d.redacts))
+	d.unitAwares = make([]*regexp.Regexp, 0, len(d.unitAwares))
+	d.normalizers = nil
+	d.dateFormats = nil
+	d.mustEquals = nil
+	d.expecteds = nil
+	d.crossRules = nil
+	d.suppressions = nil
+	d.scopedIgnores = nil
+	d.fullMatch, d.foldCase = false, false
+	d.reportEqual = false
+	d.contextSize = 0
+	d.shifts = nil
+	d.sections = nil
+	d.pathStyle = nil
+	d.mapHashing = nil
+	d.pathLabels = nil
+	d.comparatorSuffix = false
+	d.sorterRecover = false
+	d.memoized = false
+	d.maxReportBytes = 0
+	d.correlated, d.fixedID = false, ""
+	d.classified = false
+	d.positioned = false
+	d.sourceA, d.sourceB = nil, nil
+	d.severity = nil
+	d.tracer = nil
+	d.tracePaths = nil
+	d.sinks = nil
+	d.nullAsZeros = make([]*regexp.Regexp, 0, len(d.nullAsZeros))
+	d.keyFormats = make([]*keyStringer, 0, len(d.keyFormats))
+	d.kindHandlers = nil
+	d.typeComparers = nil
+	d.methods = make([]*methodRule, 0, len(d.methods))
+	d.unwraps = nil
+	d.namedUnwraps = nil
+	d.textuals = make([]*regexp.Regexp, 0, len(d.textuals))
+	d.errorRules = nil
+	d.syncMaps = nil
+	d.skipTypes = nil
+	d.compareInfra = false
+	d.tolerances = make([]*tolerance, 0, len(d.tolerances))
+	d.lenTolerances = nil
+	d.deltas = nil
+	d.keyMatchers = nil
+	d.timeWindows = nil
+	d.postHooks = nil
+	d.csvKeys = nil
+	d.migrations = nil
+	d.resetResult()
 	return d
 }
 
-func (d *Differ) Compare(a, b interface{}) *Differ {
+// resetResult clears the compare result of Differ and keeps the configuration.
+func (d *Differ) resetResult() {
+	d.redacting = 0
+	d.diffs = make(map[string]*Diff, len(d.diffs))
+	d.visited = nil
+	d.aggGroups = nil
+	d.streamErr = nil
+	d.sinkErr = nil
+	d.streamField = ""
+	d.stopped = false
+	d.diffCount = 0
+	d.violated = false
+	d.expectedDiffs = nil
+	d.equals = nil
+	d.rootType = nil
+	d.errs = nil
+	d.span = nil
+	d.nodeCount = 0
+	d.memo = nil
+	d.compareID = ""
+	d.timings = nil
+	d.elemDiffs = nil
+	d.elemDepth = 0
+	d.ruleHits = nil
+}
+
+// Compare compares a and b, and records the diffs into Differ.
+// A key present in only one of two maps is recorded as a NilDiff at the path of the key,
+// with "<not nil>" on the side having it and "<nil>" on the other side.
+//
+// If any opts is given, the comparison runs on a copy of Differ with opts
+// applied on it, and the copy will be returned, so that a shared Differ can serve
+// slightly different comparisons without Reset and reconfiguring.
+func (d *Differ) Compare(a, b interface{}, opts ...Option) *Differ {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.Compare(a, b)
+	}
+	return d.compare(a, b, nil)
+}
+
+// compare compares a and b like Compare, the time spent on comparing is recorded into timing
+// before post hooks run if timing is not nil, see CompareLazy.
+func (d *Differ) compare(a, b interface{}, timing *Timings) *Differ {
+	start := time.Now()
+	d.timings = nil
+	for _, migrate := range d.migrations {
+		a = migrate(a)
+	}
 	va, vb := ValueOf(a), ValueOf(b)
 	if va.Type() != vb.Type() {
 		typeMismatchPanic(a, b)
 	}
-	tName := va.Type().Name()
-	if va.Kind() == Ptr {
-		tName = va.Elem().Type().Name()
+	d.rootType = va.Type()
+	if d.tracer != nil {
+		defer d.trace(rootName(va))()
+	}
+	d.newComparisonID()
+	d.streamLabel()
+	d.doCompare(va, vb, rootName(va), 0)
+	d.checkCrossFields(a, b)
+	d.streamFlush()
+	d.writeSinks()
+	if timing != nil {
+		timing.Compare = time.Since(start)
+		d.timings = timing
 	}
-	d.doCompare(va, vb, iF(isStringBlank(tName), initTypeName, tName).(string), 0)
+	d.runPostHooks()
 	return d
 }
 
 func (d *Differ) doCompare(a, b Value, fieldPath string, depth int) {
+	if d.stopped {
+		return
+	}
+	if len(d.scopedIgnores) > 0 && d.getDiffMode() == ignoreMode && d.isScopedIgnored(fieldPath, a.Kind()) {
+		return
+	}
+	d.nodeCount++
+
+	if d.isTracedPath(fieldPath) {
+		defer d.trace(fieldPath)()
+	}
+
+	if d.robust {
+		defer func() {
+			if r := recover(); r != nil {
+				reason := fmt.Sprint(r)
+				d.setTypedDiff(UncomparableDiff, fieldPath, reason, reason)
+			}
+		}()
+	}
+
 	if depth > d.maxDepth {
-		panic("depth over limit")
+		panicAt("depth over limit", fieldPath, depth, a, b)
 	}
 
 	if !a.IsValid() || !b.IsValid() {
-		panic("value invalid: " + a.Type().String())
+		panicAt("value invalid", fieldPath, depth, a, b)
 	}
 
 	if a.Type() != b.Type() {
-		typeMismatchPanic(a.Type(), b.Type())
+		panicAt("type mismatch", fieldPath, depth, a, b)
 	}
 
-	for _, c := range d.comparators {
+	for i, c := range d.comparators {
 		if c.Match(fieldPath) {
-			fieldPath = fieldPath + useComparatorSuffix
-			dt, va, vb := c.Equals(a.Interface(), b.Interface())
-			switch dt {
-			case LengthDiff:
-				d.setLenDiff(fieldPath, a, b)
-			case NilDiff:
-				d.setNilDiff(fieldPath, a, b)
-			case ElemDiff:
-				d.setDiff(fieldPath, va, vb)
-			case NoDiff:
-				return
-			default:
-				panic("customized comparator returned an unexpected DiffType")
-			}
+			d.hit("comparator", i)
+			d.visit(fieldPath)
+			d.compareWith(c, a, b, fieldPath)
 			return
 		}
 	}
 
+	if fn, ok := d.typeComparers[a.Type()]; ok && a.CanInterface() {
+		d.visit(fieldPath)
+		d.checkEqual(fn.Call([]Value{a, b})[0].Bool(), fieldPath, a, b)
+		return
+	}
+
+	if d.isSkippedType(a.Type()) {
+		return
+	}
+
+	if d.unwrap(a, b, fieldPath, depth) {
+		return
+	}
+
+	if d.compareSync(a, b, fieldPath, depth) {
+		return
+	}
+
+	if d.compareErrors(a, b, fieldPath) {
+		return
+	}
+
+	if d.compareText(a, b, fieldPath) {
+		return
+	}
+
+	if d.compareTrimmed(a, b, fieldPath) {
+		return
+	}
+
+	if d.compareMethods(a, b, fieldPath, depth) {
+		return
+	}
+
+	if d.compareRecord(a, b, fieldPath, depth) {
+		return
+	}
+
+	if h, ok := d.kindHandlers[a.Kind()]; ok {
+		d.visit(fieldPath)
+		h(&Visitor{d: d, path: fieldPath, depth: depth}, a, b)
+		return
+	}
+
+	if isSQLNullType(a.Type()) && a.CanInterface() {
+		d.compareSQLNull(a, b, fieldPath)
+		return
+	}
+
 	switch a.Kind() {
 	case Array:
 		for i := 0; i < minInt(a.Len(), b.Len()); i++ {
@@ -214,30 +764,46 @@ func (d *Differ) doCompare(a, b Value, fieldPath string, depth int) {
 		}
 	case Slice:
 		if a.IsNil() != b.IsNil() {
-			d.setNilDiff(fieldPath, a, b)
+			d.setNilDiffExpanded(fieldPath, a, b, depth)
 			return
 		}
-		if a.Len() != b.Len() {
+		lenTolerated := d.withinLengthTolerance(a, b, fieldPath)
+		if a.Len() != b.Len() && !lenTolerated {
 			d.setLenDiff(fieldPath, a, b)
 		}
 		if a.Pointer() == b.Pointer() {
 			return
 		}
-		for _, s := range d.sorters {
+		for i, s := range d.sorters {
 			if s.Match(fieldPath) {
-				a, b = d.sortSlice(a, b, s)
+				d.hit("sorter", i)
+				a, b = d.sortSlice(a, b, s, fieldPath)
 				break
 			}
 		}
+		if d.compareShifted(a, b, fieldPath, depth) || d.compareTimeWindowed(a, b, fieldPath, depth) {
+			return
+		}
 		for i := 0; i < minInt(a.Len(), b.Len()); i++ {
-			d.doCompare(a.Index(i), b.Index(i),
-				concat(fieldPath, "[", strconv.Itoa(i), "]"), depth)
+			d.compareElem(a, b, i, fieldPath, depth)
+		}
+		if !lenTolerated {
+			d.setMissingDiffs(fieldPath, a, b)
 		}
 	case Interface:
 		if a.IsNil() != b.IsNil() {
 			d.setNilDiff(fieldPath, a, b)
 			return
 		}
+		if a.IsNil() {
+			return
+		}
+
+		if ea, eb := a.Elem(), b.Elem(); d.typeChanges && ea.Type() != eb.Type() {
+			d.visit(fieldPath)
+			d.setTypedDiff(ElemDiff, fieldPath, interfaceOf(ea), interfaceOf(eb))
+			return
+		}
 
 		if sa, sb, ok := parseStringValue(a, b); ok {
 			d.doCompare(sa, sb, fieldPath, depth)
@@ -264,102 +830,438 @@ func (d *Differ) doCompare(a, b Value, fieldPath string, depth int) {
 			return
 		}
 
-		panic(fmt.Sprintf("unexpected interface with type: %s", a.Type().Name()))
+		if ea, eb := a.Elem(), b.Elem(); ea.Type() == eb.Type() {
+			d.doCompare(ea, eb, fieldPath, depth)
+			return
+		} else if d.compareIndirection(ea, eb, fieldPath, depth) {
+			return
+		}
+
+		panicAt("unexpected interface", fieldPath, depth, a, b)
 
 	case Ptr:
 		if a.IsNil() != b.IsNil() {
-			d.setNilDiff(fieldPath, a, b)
+			d.setNilDiffExpanded(fieldPath, a, b, depth)
 			return
 		}
-		if a.Pointer() != b.Pointer() {
-			d.doCompare(a.Elem(), b.Elem(), fieldPath, depth)
+		if ea, eb, ok := d.indirect(a, b, fieldPath, depth); ok {
+			d.compareMemoized(a, b, ea, eb, fieldPath, depth)
 		}
 	case Struct:
 		for i, n := 0, a.NumField(); i < n; i++ {
-			d.doCompare(a.Field(i), b.Field(i), concat(fieldPath, ".", a.Type().Field(i).Name), depth+1)
+			sf := a.Type().Field(i)
+			secret := d.redactSecrets && isSecretField(sf)
+			if secret {
+				d.redacting++
+			}
+			d.doCompare(a.Field(i), b.Field(i), concat(fieldPath, ".", sf.Name), depth+1)
+			if secret {
+				d.redacting--
+			}
 		}
 	case Map:
 		if a.IsNil() != b.IsNil() {
-			d.setNilDiff(fieldPath, a, b)
+			d.setNilDiffExpanded(fieldPath, a, b, depth)
 			return
 		}
-		if a.Len() != b.Len() {
+		if a.Len() != b.Len() && !d.withinLengthTolerance(a, b, fieldPath) {
 			d.setLenDiff(fieldPath, a, b)
 		}
+		for _, s := range d.mapSorters {
+			if s.Match(fieldPath) && d.compareOrderedMap(a, b, s, fieldPath, depth) {
+				return
+			}
+		}
+		hashesA, hashesB := d.hashes(a), d.hashes(b)
+		pairs, paired := d.matchKeys(a, b, fieldPath)
 		for _, k := range a.MapKeys() {
 			v1, v2 := a.MapIndex(k), b.MapIndex(k)
-			d.doCompare(v1, v2, concat(fieldPath, "[", toString(k.Interface()), "]"), depth)
+			keyPath := concat(fieldPath, d.keySegment(fieldPath, k))
+			if kb, ok := pairs[k.Interface()]; ok && !v2.IsValid() {
+				d.doCompare(v1, b.MapIndex(kb), keyPath, depth)
+				continue
+			}
+			if !v2.IsValid() {
+				d.setEntryDiff(NilDiff, fieldPath, keyPath, notNull, null)
+				continue
+			}
+			if hashesA != nil && hashesB != nil && hashesA[k.Interface()] == hashesB[k.Interface()] {
+				continue
+			}
+			d.doCompare(v1, v2, keyPath, depth)
 		}
-	case String:
-		for _, ts := range d.trimSpaces {
-			if ts.MatchString(fieldPath) {
-				if !DeepEqual(strings.TrimSpace(a.String()), strings.TrimSpace(b.String())) {
-					d.setDiff(fieldPath, a, b)
-				}
-				return
+		for _, k := range b.MapKeys() {
+			if _, ok := paired[k.Interface()]; ok {
+				continue
+			}
+			if !a.MapIndex(k).IsValid() {
+				d.setEntryDiff(NilDiff, fieldPath, concat(fieldPath, d.keySegment(fieldPath, k)), null, notNull)
 			}
 		}
-		for _, tt := range d.trimTags {
-			if tt.fieldRegexp.MatchString(fieldPath) {
-				if !DeepEqual(tt.Trim(a.String()), tt.Trim(b.String())) {
-					d.setDiff(fieldPath, a, b)
-				}
+	case String:
+		d.visit(fieldPath)
+		if sa, ok := d.trimText(a.String(), fieldPath); ok {
+			sb, _ := d.trimText(b.String(), fieldPath)
+			d.checkEqual(sa == sb, fieldPath, a, b)
+			return
+		}
+		if equal, ok := d.compareDate(a.String(), b.String(), fieldPath); ok {
+			d.checkEqual(equal, fieldPath, a, b)
+			return
+		}
+		for _, ua := range d.unitAwares {
+			if ua.MatchString(fieldPath) && d.equalQuantity(a.String(), b.String(), fieldPath) {
+				d.setEqual(fieldPath, a, b)
 				return
 			}
 		}
 		fallthrough
 	default:
-		if !DeepEqual(a.Interface(), b.Interface()) {
-			d.setDiff(fieldPath, a, b)
-			return
+		d.visit(fieldPath)
+		d.checkEqual(primitiveEqual(a, b) || d.withinTolerance(a, b, fieldPath), fieldPath, a, b)
+	}
+}
+
+// primitiveEqual checks if a and b are equal, values of primitive kinds are compared directly
+// without being boxed into interfaces.
+func primitiveEqual(a, b Value) bool {
+	switch a.Kind() {
+	case String:
+		return a.String() == b.String()
+	case Bool:
+		return a.Bool() == b.Bool()
+	case Int, Int8, Int16, Int32, Int64:
+		return a.Int() == b.Int()
+	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		return a.Uint() == b.Uint()
+	case Float32, Float64:
+		return a.Float() == b.Float()
+	case Complex64, Complex128:
+		return a.Complex() == b.Complex()
+	}
+	return DeepEqual(a.Interface(), b.Interface())
+}
+
+type keyStringer struct {
+	fieldRegexp *regexp.Regexp
+	fn          func(key interface{}) string
+}
+
+func (d *Differ) formatMapKey(mapPath string, k Value) string {
+	for _, ks := range d.keyFormats {
+		if ks.fieldRegexp.MatchString(mapPath) {
+			return ks.fn(k.Interface())
+		}
+	}
+	return formatKey(k)
+}
+
+func (d *Differ) visit(fieldPath string) {
+	if !d.recordVisited {
+		return
+	}
+	if d.visited == nil {
+		d.visited = make(map[string]struct{}, 16)
+	}
+	d.visited[fieldPath] = struct{}{}
+}
+
+// clone copies the configuration of Differ without any compare result.
+func (d *Differ) clone() *Differ {
+	cd := NewDiffer()
+	cd.ignores = append(cd.ignores, d.ignores...)
+	cd.includes = append(cd.includes, d.includes...)
+	cd.trimSpaces = append(cd.trimSpaces, d.trimSpaces...)
+	cd.trimTags = append(cd.trimTags, d.trimTags...)
+	cd.comparators = append(cd.comparators, d.comparators...)
+	cd.sorters = append(cd.sorters, d.sorters...)
+	cd.mapSorters = append(cd.mapSorters, d.mapSorters...)
+	cd.redacts = append(cd.redacts, d.redacts...)
+	cd.unitAwares = append(cd.unitAwares, d.unitAwares...)
+	cd.normalizers = append(cd.normalizers, d.normalizers...)
+	cd.dateFormats = append(cd.dateFormats, d.dateFormats...)
+	cd.mustEquals = append(cd.mustEquals, d.mustEquals...)
+	cd.expecteds = append(cd.expecteds, d.expecteds...)
+	cd.crossRules = append(cd.crossRules, d.crossRules...)
+	cd.suppressions = append(cd.suppressions, d.suppressions...)
+	cd.scopedIgnores = append(cd.scopedIgnores, d.scopedIgnores...)
+	cd.fullMatch, cd.foldCase = d.fullMatch, d.foldCase
+	cd.reportEqual = d.reportEqual
+	cd.contextSize = d.contextSize
+	cd.shifts = append(cd.shifts, d.shifts...)
+	cd.WithPathLabels(d.pathLabels)
+	cd.comparatorSuffix = d.comparatorSuffix
+	cd.tracer = d.tracer
+	cd.sinks = append(cd.sinks, d.sinks...)
+	cd.tracePaths = append(cd.tracePaths, d.tracePaths...)
+	cd.nullAsZeros = append(cd.nullAsZeros, d.nullAsZeros...)
+	cd.keyFormats = append(cd.keyFormats, d.keyFormats...)
+	cd.methods = append(cd.methods, d.methods...)
+	cd.textuals = append(cd.textuals, d.textuals...)
+	cd.errorRules = append(cd.errorRules, d.errorRules...)
+	cd.syncMaps = append(cd.syncMaps, d.syncMaps...)
+	cd.skipTypes = append(cd.skipTypes, d.skipTypes...)
+	cd.compareInfra = d.compareInfra
+	cd.tolerances = append(cd.tolerances, d.tolerances...)
+	cd.lenTolerances = append(cd.lenTolerances, d.lenTolerances...)
+	cd.deltas = append(cd.deltas, d.deltas...)
+	cd.keyMatchers = append(cd.keyMatchers, d.keyMatchers...)
+	cd.timeWindows = append(cd.timeWindows, d.timeWindows...)
+	cd.postHooks = append(cd.postHooks, d.postHooks...)
+	cd.csvKeys = append(cd.csvKeys, d.csvKeys...)
+	cd.migrations = append(cd.migrations, d.migrations...)
+	if len(d.unwraps) > 0 {
+		cd.unwraps = make(map[Type]Unwrapper, len(d.unwraps))
+		for t, fn := range d.unwraps {
+			cd.unwraps[t] = fn
 		}
 	}
+	for name, fn := range d.namedUnwraps {
+		cd.WithUnwrapByName(name, fn)
+	}
+	for kind, h := range d.kindHandlers {
+		cd.RegisterKindHandler(kind, h)
+	}
+	for _, fn := range d.typeComparers {
+		cd.WithTypeComparer(fn.Interface())
+	}
+	cd.redactSecrets = d.redactSecrets
+	cd.aggregated = d.aggregated
+	cd.stream = d.stream
+	cd.flushPerField = d.flushPerField
+	cd.maxDepth = d.maxDepth
+	cd.maxDiffs = d.maxDiffs
+	cd.diffTmpl = d.diffTmpl
+	cd.tmpl = d.tmpl
+	cd.reportTmpl = d.reportTmpl
+	cd.pathTmpls = append(cd.pathTmpls, d.pathTmpls...)
+	cd.sectioned = d.sectioned
+	cd.sections = append(cd.sections, d.sections...)
+	if d.pathStyle != nil {
+		ps := *d.pathStyle
+		cd.pathStyle = &ps
+	}
+	cd.mapHashing = d.mapHashing
+	cd.label = d.label
+	cd.quiet = d.quiet
+	cd.robust = d.robust
+	cd.sorterRecover = d.sorterRecover
+	cd.memoized = d.memoized
+	cd.maxReportBytes = d.maxReportBytes
+	cd.correlated, cd.fixedID = d.correlated, d.fixedID
+	cd.classified = d.classified
+	cd.positioned = d.positioned
+	cd.sourceA, cd.sourceB = d.sourceA, d.sourceB
+	cd.severity = d.severity
+	cd.expandNil = d.expandNil
+	cd.recordVisited = d.recordVisited
+	return cd
 }
 
-func (d *Differ) sortSlice(sa, sb Value, sorter Sorter) (sortedSa, sortedSb Value) {
+func (d *Differ) sortSlice(sa, sb Value, sorter Sorter, fieldPath string) (sortedSa, sortedSb Value) {
+	if d.sorterRecover {
+		defer func() {
+			if r := recover(); r != nil {
+				reason := fmt.Sprint(r)
+				d.setTypedDiff(SorterDiff, fieldPath, reason, reason)
+				sortedSa, sortedSb = sa, sb
+			}
+		}()
+	}
 	// deep copy slice to avoid affect the original data.
 	sortedSa = copySliceValue(sa)
 	sortedSb = copySliceValue(sb)
-	qsort(sortedSa, sorter.Less)
-	qsort(sortedSb, sorter.Less)
+	sortBy(sortedSa, sorter, fieldPath)
+	sortBy(sortedSb, sorter, fieldPath)
 	return
 }
 
 func (d *Differ) setNilDiff(fieldName string, a, b Value) {
-	d.setDiff(fieldName, iF(a.IsNil(), null, notNull), iF(b.IsNil(), null, notNull))
+	d.setTypedDiff(NilDiff, fieldName, iF(a.IsNil(), null, notNull), iF(b.IsNil(), null, notNull))
+}
+
+// setNilDiffExpanded records a nil diff, and compares the non-nil side against
+// the zero value if WithNilExpansion is called. Only composite values are expanded,
+// so that the nil diff of a pointer to a primitive is not overwritten by the diff of its element.
+func (d *Differ) setNilDiffExpanded(fieldName string, a, b Value, depth int) {
+	d.setNilDiff(fieldName, a, b)
+	if !d.expandNil || !isCompositeType(a.Type()) {
+		return
+	}
+	if a.IsNil() {
+		a = nonNilZero(a.Type())
+	} else {
+		b = nonNilZero(b.Type())
+	}
+	d.doCompare(a, b, fieldName, depth)
 }
 
 func (d *Differ) setLenDiff(fieldName string, a, b Value) {
-	d.setDiff(fieldName+"[Length]", a.Len(), b.Len())
+	d.setTypedDiff(LengthDiff, fieldName+lengthSuffix, a.Len(), b.Len())
+}
+
+// setMissingDiffs records elements beyond the shorter length of slice a and b,
+// the missing side is rendered as "<missing>".
+func (d *Differ) setMissingDiffs(fieldName string, a, b Value) {
+	for i := b.Len(); i < a.Len() && !d.stopped; i++ {
+		d.setEntryDiff(MissingDiff, fieldName, concat(fieldName, "[", strconv.Itoa(i), "]"),
+			renderMissing(a.Index(i)), missing)
+	}
+	for i := a.Len(); i < b.Len() && !d.stopped; i++ {
+		d.setEntryDiff(MissingDiff, fieldName, concat(fieldName, "[", strconv.Itoa(i), "]"),
+			missing, renderMissing(b.Index(i)))
+	}
+}
+
+// renderMissing renders v with at most maxMissingValueLen characters.
+func renderMissing(v Value) string {
+	var s string
+	if v.CanInterface() {
+		s = fmt.Sprintf("%+v", v.Interface())
+	} else {
+		s = fmt.Sprintf("%+v", v)
+	}
+	if r := []rune(s); len(r) > maxMissingValueLen {
+		s = string(r[:maxMissingValueLen]) + "..."
+	}
+	return s
+}
+
+// checkEqual records a diff of fieldName if a and b are not equal, or else records them as equal.
+// Diffs of primitives are recorded without boxing a and b into interfaces.
+func (d *Differ) checkEqual(equal bool, fieldName string, a, b Value) {
+	if equal {
+		d.setEqual(fieldName, a, b)
+		return
+	}
+	pa, okA := primitiveOf(a)
+	pb, okB := primitiveOf(b)
+	if okA && okB {
+		d.setPrimitiveDiff(fieldName, pa, pb)
+		return
+	}
+	d.setDiff(fieldName, a, b)
 }
 
 func (d *Differ) setDiff(fieldName string, va, vb interface{}) {
+	d.setTypedDiff(ElemDiff, fieldName, va, vb)
+}
+
+// setPrimitiveDiff records an ElemDiff of primitives a and b, they are boxed only if
+// a delta threshold, expected diff or redaction needs their values.
+func (d *Differ) setPrimitiveDiff(fieldName string, a, b primitive) {
+	if len(d.deltas) > 0 || len(d.expecteds) > 0 || d.isRedactedField(fieldName) {
+		d.setDiff(fieldName, a.value(), b.value())
+		return
+	}
+	if !d.isReportedField(fieldName) || !d.countDiff(fieldName) {
+		return
+	}
+	d.addDiff(fieldName, &Diff{name: fieldName, pa: a, pb: b, dt: ElemDiff, count: 1})
+}
+
+func (d *Differ) setTypedDiff(dt DiffType, fieldName string, va, vb interface{}) {
+	if !d.isReportedField(fieldName) {
+		return
+	}
+	if d.isRedactedField(fieldName) {
+		va, vb = redacted, redacted
+	}
+	va, vb = interfaceOf(va), interfaceOf(vb)
+	if dt == ElemDiff && len(d.deltas) > 0 && d.belowDeltaThreshold(fieldName, va, vb) {
+		return
+	}
+	if len(d.expecteds) > 0 && d.isExpectedDiff(fieldName, va, vb) {
+		if d.expectedDiffs == nil {
+			d.expectedDiffs = make(map[string]*Diff)
+		}
+		df := newDiff(d.stylePath(fieldName), va, vb)
+		df.dt = dt
+		d.expectedDiffs[fieldName] = df
+		return
+	}
+	if !d.countDiff(fieldName) {
+		return
+	}
+	df := newDiff(fieldName, va, vb)
+	df.dt = dt
+	d.addDiff(fieldName, df)
+}
+
+// isReportedField checks if fieldName is reported according to includes and ignores,
+// and counts the hit of the rule deciding it, see RuleStats.
+func (d *Differ) isReportedField(fieldName string) bool {
 	switch d.getDiffMode() {
 	case includeMode:
-		if !d.isIncludedField(fieldName) {
-			return
+		i := matchIndex(d.includes, fieldName)
+		if i >= 0 {
+			d.hit("include", i)
 		}
+		return i >= 0
 	case ignoreMode:
-		if d.isIgnoredField(fieldName) {
-			return
+		if i := matchIndex(d.ignores, fieldName); i >= 0 {
+			d.hit("ignore", i)
+			return false
 		}
+		return !d.isSuppressedField(fieldName) && !d.isScopedIgnored(fieldName, Invalid)
+	}
+	return true
+}
+
+// countDiff counts a diff of fieldName, it returns false if the diff is not to be recorded in quiet mode.
+func (d *Differ) countDiff(fieldName string) bool {
+	d.diffCount++
+	if !d.violated && d.isMustEqualField(fieldName) {
+		d.violated = true
+	}
+	if d.quiet {
+		d.stopped = true
+		return false
+	}
+	return true
+}
+
+// addDiff records df found at fieldName.
+func (d *Differ) addDiff(fieldName string, df *Diff) {
+	df.byComparator = d.byComparator
+	df.sourceA, df.sourceB = d.sourceA, d.sourceB
+	df.compareID = d.compareID
+	if d.aggregated && d.aggregate(df) {
+		return
+	}
+	if df.dt != CrossFieldDiff {
+		df.name = d.stylePath(fieldName)
+	}
+	d.diffs[fieldName] = df
+	if d.elemDepth > 0 {
+		d.elemDiffs = append(d.elemDiffs, df)
+	}
+	d.streamDiff(df)
+	if d.maxDiffs > 0 && len(d.diffs) >= d.maxDiffs {
+		d.stopped = true
 	}
-	d.diffs[fieldName] = newDiff(fieldName, va, vb)
 }
 
 func (d *Differ) getDiffMode() diffMode {
 	if len(d.includes) > 0 {
 		return includeMode
 	}
-	if len(d.ignores) > 0 {
+	if len(d.ignores) > 0 || len(d.suppressions) > 0 || len(d.scopedIgnores) > 0 {
 		return ignoreMode
 	}
 	return allDiffMode
 }
 
 func (d *Differ) isIncludedField(fieldName string) bool {
-	for _, ic := range d.includes {
-		if ic.MatchString(fieldName) {
+	return matchIndex(d.includes, fieldName) >= 0
+}
+
+func (d *Differ) isRedactedField(fieldName string) bool {
+	if d.redacting > 0 {
+		return true
+	}
+	for _, r := range d.redacts {
+		if r.MatchString(fieldName) {
 			return true
 		}
 	}
@@ -367,14 +1269,29 @@ func (d *Differ) isIncludedField(fieldName string) bool {
 }
 
 func (d *Differ) isIgnoredField(fieldName string) bool {
-	for _, ig := range d.ignores {
-		if ig.MatchString(fieldName) {
-			return true
-		}
+	if matchIndex(d.ignores, fieldName) >= 0 {
+		return true
 	}
-	return false
+	return d.isSuppressedField(fieldName) || d.isScopedIgnored(fieldName, Invalid)
 }
 
 func typeMismatchPanic(a, b interface{}) {
 	panic("type mismatch: " + newDiff("type", a, b).String())
 }
+
+// panicAt panics with reason, the field path, depth and types of a and b, such as
+// "type mismatch at Person.Extra (depth 1): A is interface {}(int), B is interface {}(string)".
+func panicAt(reason, fieldPath string, depth int, a, b Value) {
+	panic(fmt.Sprintf("%s at %s (depth %d): A is %s, B is %s", reason, fieldPath, depth, typeString(a), typeString(b)))
+}
+
+// typeString returns the type of v, with the dynamic type if v is a non-nil interface.
+func typeString(v Value) string {
+	if !v.IsValid() {
+		return "<invalid>"
+	}
+	if v.Kind() == Interface && !v.IsNil() {
+		return concat(v.Type().String(), "(", v.Elem().Type().String(), ")")
+	}
+	return v.Type().String()
+}
@@ -1,11 +1,33 @@
 package sdiffer
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -200,6 +222,2386 @@ func (suite *DiffTestSuite) TestRawInterfaceCompare() {
 	fmt.Println(NewDiffer().Compare(any, any2).String())
 }
 
+func (suite *DiffTestSuite) TestEqual() {
+	me := &Person{Name: "sjl", Parents: []*Person{{Name: "p1", Age: 30}, {Name: "p2", Age: 40}}}
+	he := &Person{Name: "sjl", Parents: []*Person{{Name: "p2", Age: 40}, {Name: "p1", Age: 30}}}
+	suite.False(Equal(me, he))
+	suite.True(Equal(me, he, OptSorter(&pSorter{regexp.MustCompile("Person.Parents")})))
+	suite.True(Equal(me, he, OptIgnore("Person.Parents")))
+}
+
+func (suite *DiffTestSuite) TestCompareWithOptions() {
+	me := &Person{Name: "me", Age: 20, StrArr: []string{"a", "b"}}
+	he := &Person{Name: "he", Age: 21, StrArr: []string{"b", "a"}}
+	base := NewDiffer().Ignore("Person.StrArr")
+
+	differ := base.Compare(me, he, OptExtraIgnores("Person.Age"), OptLabel("no age"))
+	suite.NotEqual(base, differ)
+	suite.Len(differ.Diffs(), 1)
+	suite.Equal("no age", differ.Label())
+	suite.Len(base.Diffs(), 0)
+
+	suite.Len(base.Compare(me, he, OptMaxDiffs(1)).Diffs(), 1)
+	suite.Len(base.Compare(me, he).Diffs(), 2)
+}
+
+func (suite *DiffTestSuite) TestCompareResults() {
+	me := &Person{Name: "me", Age: 20}
+	yesterday := NewDiffer().Compare(me, &Person{Name: "he", Age: 20}).Diffs()
+	today := NewDiffer().Compare(me, &Person{Name: "me", Age: 21}).Diffs()
+	appeared, resolved := CompareResults(yesterday, today)
+	suite.Len(appeared, 1)
+	suite.Equal("Person.Age", appeared[0].Name())
+	suite.Len(resolved, 1)
+	suite.Equal("Person.Name", resolved[0].Name())
+}
+
+func (suite *DiffTestSuite) TestHasDiffs() {
+	differ := NewDiffer().Compare(&Person{Name: "me"}, &Person{Name: "he"})
+	suite.True(differ.HasDiffs())
+	suite.True(differ.HasDiffsMatching(`^Person\.Name$`))
+	suite.False(differ.HasDiffsMatching(`Person\.Age`))
+	suite.Panics(func() { differ.HasDiffsMatching(`Person[`) })
+	_, err := differ.HasDiffsMatchingE(`Person[`)
+	suite.Error(err)
+	ok, err := differ.HasDiffsMatchingE(`Name$`)
+	suite.NoError(err)
+	suite.True(ok)
+	suite.True(allowPanic(func() { differ.MustBeEqual() }))
+	suite.False(allowPanic(func() { differ.Reset().Compare(&Person{}, &Person{}).MustBeEqual() }))
+}
+
+func (suite *DiffTestSuite) TestVisitedPaths() {
+	me := &Person{Name: "me", Loc: newLoc("JiAn"), StrArr: []string{"a"}}
+	he := &Person{Name: "he", Loc: newLoc("JiAn"), StrArr: []string{"b"}}
+	suite.Nil(NewDiffer().Compare(me, he).VisitedPaths())
+	paths := NewDiffer().WithVisitedPaths().Compare(me, he).VisitedPaths()
+	suite.Equal([]string{"Person.Age", "Person.Loc.Name", "Person.Name", "Person.StrArr[0]"}, paths)
+}
+
+func (suite *DiffTestSuite) TestValidate() {
+	_, err := NewDiffer().IgnoreE(`Person.(`)
+	suite.Error(err)
+	_, err = NewDiffer().IncludesE(`Person.Name`, `[`)
+	suite.Error(err)
+
+	suite.NoError(NewDiffer().Validate())
+	suite.NoError(NewDiffer().WithTmpl(`%s: %v => %v (100%%)`).Validate())
+	suite.Error(NewDiffer().WithTmpl(`%s: %v`).Validate())
+	suite.Error(NewDiffer().WithMaxDepth(0).Validate())
+}
+
+func (suite *DiffTestSuite) TestTemplate() {
+	me := &Person{Name: "me", StrArr: []string{"a"}}
+	he := &Person{Name: "he"}
+	differ := NewDiffer().WithTemplate(`{{.Type}} {{.Path}}: {{.A}} => {{.B}}`).Compare(me, he)
+	df, _ := differ.FindDiff("Person.Name")
+	suite.Equal("ElemDiff Person.Name: me => he", differ.formatDiff(df, ""))
+	df, _ = differ.FindDiff("Person.StrArr")
+	suite.Equal("NilDiff Person.StrArr: <not nil> => <nil>", differ.formatDiff(df, ""))
+
+	report := NewDiffer().WithLabel("people").Includes("Person.Name").
+		WithReportTemplate(`{{.Label}}: {{.Count}}{{range .Diffs}} [{{.Text}}]{{end}}`).Compare(me, he).String()
+	suite.Equal(`people: 1 [Field: "Person.Name", A: me, B: he]`, report)
+}
+
+func (suite *DiffTestSuite) TestSections() {
+	me := &Person{Name: "me", Loc: &Location{"JiAn", newLoc("JiangXi")}}
+	he := &Person{Name: "he", Loc: &Location{"NanChang", newLoc("JiangXi")}}
+	report := NewDiffer().WithSections().WithPathTemplate(`^Person\.Name$`, `{{.Path}}!`).Compare(me, he).String()
+	suite.Equal("== Loc ==\n"+`Field: "Person.Loc.Name", A: JiAn, B: NanChang`+"\n== Name ==\nPerson.Name!\n", report)
+	suite.Equal("[0]", topLevelField("$[0].Name"))
+}
+
+func (suite *DiffTestSuite) TestCompareJSON() {
+	const (
+		doc1 = `{
+  "name": "sjl",
+  "tags": [1, 2]
+}`
+		doc2 = `{
+  "name": "sjl",
+  "tags": [1,
+    3]
+}`
+	)
+	differ, err := NewDiffer().CompareJSON([]byte(doc1), []byte(doc2))
+	suite.NoError(err)
+	df, ok := differ.FindDiff("$[tags][1]")
+	suite.True(ok)
+	pa, _ := df.PosA()
+	pb, _ := df.PosB()
+	suite.Equal(Pos{Line: 3, Column: 15}, pa)
+	suite.Equal(Pos{Line: 4, Column: 5}, pb)
+
+	_, err = NewDiffer().CompareJSON([]byte(doc1), []byte(`{`))
+	suite.Error(err)
+
+	differ, err = NewDiffer().CompareJSON([]byte(doc1), []byte(` null`))
+	suite.NoError(err)
+	df, ok = differ.FindDiff("$")
+	suite.True(ok)
+	suite.Equal(NilDiff, df.Type())
+	pb, _ = df.PosB()
+	suite.Equal(Pos{Line: 1, Column: 2}, pb)
+	differ, err = NewDiffer().CompareJSON([]byte(`null`), []byte(`null`))
+	suite.NoError(err)
+	suite.False(differ.HasDiffs())
+	differ, err = NewDiffer().CompareJSON([]byte(`{"a":1,"b":{"c":[1]}}`), []byte(`{"a":"1","b":{"c":{}}}`))
+	suite.NoError(err)
+	suite.Len(differ.Diffs(), 2)
+	df, _ = differ.FindDiff("$[a]")
+	suite.Equal(ElemDiff, df.Type())
+	suite.Equal(float64(1), df.A())
+	suite.Equal("1", df.B())
+	pb, _ = df.PosB()
+	suite.Equal(Pos{Line: 1, Column: 6}, pb)
+	df, _ = differ.FindDiff("$[b][c]")
+	suite.Equal([]interface{}{float64(1)}, df.A())
+
+	differ, err = NewDiffer().CompareJSON([]byte(`{}`), []byte(`[]`))
+	suite.NoError(err)
+	df, ok = differ.FindDiff("$")
+	suite.True(ok)
+	suite.Equal(ElemDiff, df.Type())
+	suite.Panics(func() { NewDiffer().Compare(map[string]interface{}{"a": 1}, map[string]interface{}{"a": "1"}) })
+}
+
+func (suite *DiffTestSuite) TestCompareConfig() {
+	type Config struct {
+		Timeout  string
+		MaxBody  string
+		Password string `secret:"true"`
+		Replicas int
+	}
+	c1 := &Config{Timeout: "5s", MaxBody: "1Gi", Password: "123", Replicas: 1}
+	c2 := &Config{Timeout: "5000ms", MaxBody: "1073741824", Password: "456", Replicas: 2}
+	differ := CompareConfig(c1, c2)
+	suite.Len(differ.Diffs(), 2)
+	df, ok := differ.FindDiff("Config.Password")
+	suite.True(ok)
+	suite.Equal(redacted, df.Va())
+	_, ok = differ.FindDiff("Config.Replicas")
+	suite.True(ok)
+	suite.Contains(differ.String(), configLabel)
+}
+
+func (suite *DiffTestSuite) TestSQLNull() {
+	type Row struct {
+		Name  sql.NullString
+		Score sql.NullInt64
+		Memo  sql.NullString
+	}
+	r1 := &Row{Name: sql.NullString{String: "sjl", Valid: true}, Memo: sql.NullString{String: "", Valid: true}}
+	r2 := &Row{Name: sql.NullString{String: "kxc", Valid: true}, Score: sql.NullInt64{Int64: 1, Valid: true}}
+
+	differ := NewDiffer().Compare(r1, r2)
+	suite.Len(differ.Diffs(), 3)
+	df, _ := differ.FindDiff("Row.Name")
+	suite.Equal("sjl", df.Va())
+	df, _ = differ.FindDiff("Row.Score")
+	suite.Equal(null, df.Va())
+
+	differ = NewDiffer().WithNullAsZero(`Row\.Memo`).Compare(r1, r2)
+	_, ok := differ.FindDiff("Row.Memo")
+	suite.False(ok)
+}
+
+func (suite *DiffTestSuite) TestMapKey() {
+	type Key struct {
+		Z int
+		A string
+	}
+	m1 := map[*Key]int{{Z: 1, A: "x"}: 1}
+	m2 := map[*Key]int{}
+	for k := range m1 {
+		m2[k] = 2
+	}
+	differ := NewDiffer().Compare(m1, m2)
+	_, ok := differ.FindDiff("$[{A:x,Z:1}]")
+	suite.True(ok)
+
+	differ = NewDiffer().WithKeyStringer(`^\$$`, func(key interface{}) string {
+		return key.(*Key).A
+	}).Compare(m1, m2)
+	_, ok = differ.FindDiff("$[x]")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestAggregation() {
+	me, he := &Person{}, &Person{}
+	for i := 0; i < 10; i++ {
+		me.Parents = append(me.Parents, &Person{Age: 40, Name: strconv.Itoa(i)})
+		he.Parents = append(he.Parents, &Person{Age: 41, Name: strconv.Itoa(i)})
+	}
+	he.Parents[9].Age = 42
+	differ := NewDiffer().WithAggregation().Compare(me, he)
+	suite.Len(differ.Diffs(), 2)
+	df, ok := differ.FindDiff("Person.Parents[0].Age")
+	suite.True(ok)
+	suite.Equal(9, df.Count())
+	suite.Equal([]string{"[0]", "[1]", "[2]", "[3]", "[4]"}, df.Samples())
+	suite.Contains(differ.String(), "(9 times, e.g. [0] [1] [2] [3] [4])")
+}
+
+func (suite *DiffTestSuite) TestWriteReport() {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	me := &Person{Name: "me", Loc: newLoc("JiAn")}
+	he := &Person{Name: "he", Loc: newLoc("NanChang")}
+	differ := NewDiffer().WithLabel("people").WriteReport(w).WithFlushPerField().Compare(me, he)
+	suite.NoError(differ.StreamErr())
+	suite.Contains(buf.String(), "[people]\n")
+	suite.Contains(buf.String(), `Field: "Person.Name", A: me, B: he`)
+	suite.Contains(buf.String(), `Field: "Person.Loc.Name", A: JiAn, B: NanChang`)
+}
+
+func (suite *DiffTestSuite) TestRender() {
+	differ := NewDiffer().Compare(&Person{Name: "me", Age: 1}, &Person{Name: "he", Age: 2})
+	report := differ.String()
+	suite.Equal(report, differ.String())
+	suite.Equal("Field: \"Person.Age\", A: 1, B: 2\nField: \"Person.Name\", A: me, B: he\n", report)
+	suite.Equal("Person.Age: 1 -> 2\nPerson.Name: me -> he\n", differ.Render("%s: %v -> %v"))
+	suite.Equal(report, differ.String())
+}
+
+func (suite *DiffTestSuite) TestDiffGetters() {
+	differ := NewDiffer().Compare(&Person{Age: 20, StrArr: []string{}}, &Person{Age: 21})
+	df, _ := differ.FindDiff("Person.Age")
+	suite.Equal("Person.Age", df.Path())
+	suite.Equal(ElemDiff, df.Type())
+	var age int64
+	suite.True(ValueAs(df.A(), &age))
+	suite.Equal(int64(20), age)
+	var name string
+	suite.False(ValueAs(df.B(), &name))
+	df, _ = differ.FindDiff("Person.StrArr")
+	suite.Equal(NilDiff, df.Type())
+}
+
+func (suite *DiffTestSuite) TestKindHandler() {
+	type Handler struct {
+		Name string
+		Fn   func() string
+	}
+	h1 := &Handler{Name: "a", Fn: func() string { return "a" }}
+	h2 := &Handler{Name: "a", Fn: h1.Fn}
+	suite.True(NewDiffer().Compare(h1, h2).HasDiffs())
+
+	funcByName := func(v *Visitor, a, b reflect.Value) {
+		na, nb := runtime.FuncForPC(a.Pointer()).Name(), runtime.FuncForPC(b.Pointer()).Name()
+		if na != nb {
+			v.Report(ElemDiff, na, nb)
+		}
+	}
+	differ := NewDiffer().RegisterKindHandler(reflect.Func, funcByName)
+	suite.False(differ.Compare(h1, h2).HasDiffs())
+	h2.Fn = func() string { return "b" }
+	suite.True(differ.Reset().RegisterKindHandler(reflect.Func, funcByName).Compare(h1, h2).HasDiffsMatching(`Handler\.Fn`))
+}
+
+func (suite *DiffTestSuite) TestTypeComparer() {
+	type Event struct {
+		At   time.Time
+		Next *Event
+	}
+	now := time.Now()
+	e1 := &Event{At: now, Next: &Event{At: now}}
+	e2 := &Event{At: now.In(time.UTC), Next: &Event{At: now.Add(time.Second)}}
+	differ := NewDiffer().WithTypeComparer(func(a, b time.Time) bool { return a.Equal(b) }).Compare(e1, e2)
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Event.Next.At")
+	suite.True(ok)
+	suite.True(allowPanic(func() { NewDiffer().WithTypeComparer(func(a time.Time) bool { return true }) }))
+}
+
+type checksum struct {
+	Data []int
+	Note string
+}
+
+func (c *checksum) Sum() (sum int) {
+	for _, i := range c.Data {
+		sum += i
+	}
+	return
+}
+
+func (suite *DiffTestSuite) TestMethod() {
+	c1 := &checksum{Data: []int{1, 2, 3}, Note: "a"}
+	c2 := &checksum{Data: []int{3, 2, 1}, Note: "b"}
+	suite.False(NewDiffer().WithMethod(`^checksum$`, "Sum").Compare(c1, c2).HasDiffs())
+
+	differ := NewDiffer().WithMethodAndFields(`^checksum$`, "Sum").Compare(c1, c2)
+	suite.True(differ.HasDiffsMatching(`checksum\.Note`))
+	suite.False(differ.HasDiffsMatching(`Sum\(\)`))
+
+	c2.Data = []int{1}
+	_, ok := NewDiffer().WithMethod(`^checksum$`, "Sum").Compare(c1, c2).FindDiff("checksum.Sum()")
+	suite.True(ok)
+}
+
+type optionalInt struct {
+	Val   int
+	Valid bool
+}
+
+func (suite *DiffTestSuite) TestUnwrap() {
+	type Box struct {
+		Size optionalInt
+	}
+	b1, b2 := &Box{optionalInt{1, true}}, &Box{optionalInt{2, true}}
+	_, ok := NewDiffer().Compare(b1, b2).FindDiff("Box.Size.Val")
+	suite.True(ok)
+
+	differ := NewDiffer().WithUnwrap(func(o optionalInt) interface{} {
+		return iF(o.Valid, o.Val, nil)
+	}).Compare(b1, b2)
+	_, ok = differ.FindDiff("Box.Size")
+	suite.True(ok)
+
+	b2.Size.Valid = false
+	differ = NewDiffer().WithUnwrapByName("optionalInt", func(v reflect.Value) reflect.Value {
+		return v.FieldByName("Valid")
+	}).Compare(b1, b2)
+	df, _ := differ.FindDiff("Box.Size")
+	suite.Equal(false, df.B())
+}
+
+func (suite *DiffTestSuite) TestTextual() {
+	type Host struct {
+		IP  net.IP
+		URL url.URL
+	}
+	h1 := &Host{IP: net.ParseIP("127.0.0.1"), URL: url.URL{Scheme: "http", Host: "a.com"}}
+	h2 := &Host{IP: net.IPv4(127, 0, 0, 1), URL: url.URL{Scheme: "http", Host: "b.com"}}
+	differ := NewDiffer().WithTextual().Compare(h1, h2)
+	suite.Len(differ.Diffs(), 1)
+	df, _ := differ.FindDiff("Host.URL")
+	suite.Equal("http://a.com", df.A())
+}
+
+func (suite *DiffTestSuite) TestCompareAll() {
+	differ := NewDiffer().Ignore("Person.Age")
+	br := differ.CompareAll([]Pair{
+		{&Person{Name: "a", Age: 1}, &Person{Name: "a", Age: 2}},
+		{&Person{Name: "a"}, &Person{Name: "b"}},
+	})
+	suite.Len(br.Results, 2)
+	suite.True(br.HasDiffs())
+	suite.Equal([]int{1}, br.Indices())
+	_, ok := br.Results[1].FindDiff("Person.Name")
+	suite.True(ok)
+	suite.False(differ.HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestReconcile() {
+	a := []*Person{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 3}}
+	b := []*Person{{Name: "d", Age: 4}, {Name: "c", Age: 3}, {Name: "b", Age: 20}}
+	rc := NewDiffer().Reconcile(a, b, func(r interface{}) string {
+		return r.(*Person).Name
+	})
+	suite.True(rc.HasDiffs())
+	suite.Equal([]string{"a"}, rc.OnlyInA)
+	suite.Equal([]string{"d"}, rc.OnlyInB)
+	suite.Len(rc.Diffs, 1)
+	_, ok := rc.Diffs["b"].FindDiff("Person.Age")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestCompareCSV() {
+	const (
+		csv1 = "id,name,price,memo\n1,apple,1.00,a\n2,banana,2.00,b\n3,cherry,3.00,c\n"
+		csv2 = "price,id,name\n1.001,1, apple \n2.5,2,banana\n4.00,4,durian\n"
+	)
+	cr, err := CompareCSV(strings.NewReader(csv1), strings.NewReader(csv2),
+		OptCSVKeys("id"), OptTolerance(`\[price\]$`, 0.01), OptTrimSpace(`\[name\]$`))
+	suite.NoError(err)
+	suite.Equal([]string{"3"}, cr.OnlyInA)
+	suite.Equal([]string{"4"}, cr.OnlyInB)
+	suite.Equal([]string{"memo"}, cr.ColumnsOnlyInA)
+	suite.Len(cr.Diffs, 1)
+	suite.Equal(&CSVCellDiff{Key: "2", Column: "price", RowA: 2, RowB: 2, ColA: 3, ColB: 1, A: "2.00", B: "2.5"}, cr.Diffs[0])
+
+	cr, err = CompareCSV(strings.NewReader("id,unit.price\n1,1\n"), strings.NewReader("unit.price,id\n2,1\n"), OptCSVKeys("id"))
+	suite.NoError(err)
+	suite.Equal(&CSVCellDiff{Key: "1", Column: "unit.price", RowA: 1, RowB: 1, ColA: 2, ColB: 1, A: "1", B: "2"}, cr.Diffs[0])
+
+	cr, err = CompareCSV(strings.NewReader("id,name\n1,a\n1,b\n"), strings.NewReader("id,name\n1,a\n2,c\n2,c\n"), OptCSVKeys("id"))
+	suite.NoError(err)
+	suite.True(cr.HasDiffs())
+	suite.Equal([]string{"1"}, cr.DuplicateInA)
+	suite.Equal([]string{"2"}, cr.DuplicateInB)
+	suite.Equal([]string{"2"}, cr.OnlyInB)
+	suite.Empty(cr.Diffs)
+}
+
+func (suite *DiffTestSuite) TestCompareHeader() {
+	h1 := http.Header{"Content-Type": {"application/json"}, "Accept": {"a", "b"}, "Date": {"today"}}
+	h2 := http.Header{"content-type": {"text/plain"}, "Accept": {"b", "a"}, "X-Extra": {"1"}}
+	differ := CompareHeader(h1, h2, NoisyHeaders...)
+	suite.Len(differ.Diffs(), 2)
+	df, _ := differ.FindDiff("Header[Content-Type][0]")
+	suite.Equal("text/plain", df.B())
+	df, _ = differ.FindDiff("Header[X-Extra]")
+	suite.Equal(NilDiff, df.Type())
+
+	q1, _ := url.ParseQuery("a=1&a=2&t=now")
+	q2, _ := url.ParseQuery("a=2&a=1&t=later")
+	suite.False(CompareQuery(q1, q2, "t").HasDiffs())
+
+	q1, _ = url.ParseQuery("filter.name=a&page[size]=1")
+	q2, _ = url.ParseQuery("filter.name=b&page[size]=1")
+	differ = CompareQuery(q1, q2)
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff(`Query["filter.name"][0]`)
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestCompareHAR() {
+	const (
+		har1 = `{"log":{"entries":[{"request":{"method":"GET","url":"http://a.com/x?b=1&a=2","headers":[{"name":"Date","value":"1"}]},
+"response":{"status":200,"headers":[{"name":"Content-Type","value":"application/json"}],"content":{"text":"{\"id\":1,\"tags\":[\"a\"]}"}}}]}}`
+		har2 = `{"log":{"entries":[{"request":{"method":"GET","url":"http://a.com/x?a=2&b=3","headers":[{"name":"Date","value":"2"}]},
+"response":{"status":500,"headers":[{"name":"content-type","value":"application/json"}],"content":{"text":"{\"id\":1,\"tags\":[\"b\"]}"}}}]}}`
+	)
+	differ, err := CompareHAR(strings.NewReader(har1), strings.NewReader(har2), NoisyHeaders...)
+	suite.NoError(err)
+	suite.Len(differ.Diffs(), 3)
+	for _, path := range []string{"Entries[0].Request.Query[b][0]", "Entries[0].Response.Status", "Entries[0].Response.Body[tags][0]"} {
+		_, ok := differ.FindDiff(path)
+		suite.True(ok, path)
+	}
+	differ = CompareExchange(&Exchange{Status: 200, ResponseBody: []byte(`{"id":1,"tags":["a"]}`)},
+		&Exchange{Status: 200, ResponseBody: []byte(`{"id":"x","tags":{}}`)})
+	suite.Len(differ.Diffs(), 2)
+	df, _ := differ.FindDiff("Response.Body[id]")
+	suite.Equal(ElemDiff, df.Type())
+	suite.Equal("x", df.B())
+}
+
+func (suite *DiffTestSuite) TestCompareEnv() {
+	a := ParseEnv([]string{"HOME=/root", "DB_PASSWORD=123", "MODE=dev"})
+	b := ParseEnv([]string{"HOME=/root", "DB_PASSWORD=456", "DEBUG=1"})
+	changes := CompareEnv(a, b)
+	suite.Equal([]*EnvChange{
+		{Key: "DB_PASSWORD", Kind: Changed, A: redacted, B: redacted},
+		{Key: "DEBUG", Kind: Added, B: "1"},
+		{Key: "MODE", Kind: Removed, A: "dev"},
+	}, changes)
+
+	fa, fb := flag.NewFlagSet("a", flag.ContinueOnError), flag.NewFlagSet("b", flag.ContinueOnError)
+	fa.Int("port", 80, "")
+	fb.Int("port", 80, "")
+	suite.NoError(fb.Parse([]string{"-port", "8080"}))
+	suite.Equal("~ port: 80 => 8080", CompareFlags(fa, fb)[0].String())
+}
+
+func (suite *DiffTestSuite) TestDirDiffer() {
+	dirA, err := ioutil.TempDir("", "sdiffer")
+	suite.NoError(err)
+	defer os.RemoveAll(dirA)
+	dirB, err := ioutil.TempDir("", "sdiffer")
+	suite.NoError(err)
+	defer os.RemoveAll(dirB)
+	write := func(dir, name, content string) {
+		suite.NoError(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		suite.NoError(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write(dirA, "conf/app.json", `{"port": 80, "name": "a"}`)
+	write(dirB, "conf/app.json", `{"name": "a", "port": 8080}`)
+	write(dirA, "README", "hello")
+	write(dirB, "README", "hellO")
+	write(dirA, "only-a.txt", "")
+
+	differ, err := NewDirDiffer().WithStructural(NewDiffer()).Compare(dirA, dirB)
+	suite.NoError(err)
+	suite.Len(differ.Diffs(), 3)
+	for _, path := range []string{`Files["conf/app.json"].Content[port]`, "Files[README].Hash", `Files["only-a.txt"]`} {
+		_, ok := differ.FindDiff(path)
+		suite.True(ok, path)
+	}
+	write(dirA, "list.json", `[1]`)
+	write(dirB, "list.json", `{"a":1}`)
+	write(dirA, "empty.json", `null`)
+	write(dirB, "empty.json", `{}`)
+	differ, err = NewDirDiffer().WithStructural(NewDiffer()).Compare(dirA, dirB)
+	suite.NoError(err)
+	suite.Len(differ.Diffs(), 5)
+	df, _ := differ.FindDiff(`Files["list.json"].Content`)
+	suite.Equal(ElemDiff, df.Type())
+	df, _ = differ.FindDiff(`Files["empty.json"].Content`)
+	suite.Equal(NilDiff, df.Type())
+}
+
+func (suite *DiffTestSuite) TestCompareEncoded() {
+	encode := func(p *Person) []byte {
+		buf := &bytes.Buffer{}
+		suite.NoError(gob.NewEncoder(buf).Encode(p))
+		return buf.Bytes()
+	}
+	codec := GobCodec(func() interface{} { return &Person{} })
+	differ, err := NewDiffer().CompareEncoded(encode(&Person{Name: "me", Age: 1}), encode(&Person{Name: "me", Age: 2}), codec)
+	suite.NoError(err)
+	_, ok := differ.FindDiff("Person.Age")
+	suite.True(ok)
+
+	msgpackLike := Decoder(func(data []byte) (interface{}, error) {
+		return map[interface{}]interface{}{"n": map[string]int8{"1": 1}[string(data)], "s": []byte("x")}, nil
+	})
+	differ, err = NewDiffer().CompareEncoded([]byte("1"), []byte("2"), NormalizeNumbers(msgpackLike))
+	suite.NoError(err)
+	df, _ := differ.FindDiff("$[n]")
+	suite.Equal(float64(1), df.A())
+
+	differ, err = NewDiffer().CompareEncoded([]byte(`null`), []byte(`{}`), JSONCodec)
+	suite.NoError(err)
+	df, _ = differ.FindDiff("$")
+	suite.Equal(NilDiff, df.Type())
+	differ, err = NewDiffer().CompareEncoded([]byte(`[1]`), []byte(`{"a":1}`), JSONCodec)
+	suite.NoError(err)
+	df, _ = differ.FindDiff("$")
+	suite.Equal(ElemDiff, df.Type())
+	suite.Equal([]interface{}{float64(1)}, df.A())
+}
+
+type mapRecord struct {
+	fields []string
+	values map[string]interface{}
+}
+
+func (r *mapRecord) Fields() []string {
+	return r.fields
+}
+
+func (r *mapRecord) Get(field string) interface{} {
+	return r.values[field]
+}
+
+func (suite *DiffTestSuite) TestRecord() {
+	r1 := &mapRecord{[]string{"id", "amount", "memo"}, map[string]interface{}{"id": 1, "amount": 1.5, "memo": "x"}}
+	r2 := &mapRecord{[]string{"id", "amount", "extra"}, map[string]interface{}{"id": 1, "amount": 2.5, "extra": true}}
+	differ := NewDiffer().Compare(r1, r2)
+	suite.Len(differ.Diffs(), 3)
+	df, _ := differ.FindDiff("mapRecord.amount")
+	suite.Equal(2.5, df.B())
+	df, _ = differ.FindDiff("mapRecord.extra")
+	suite.Equal(NilDiff, df.Type())
+}
+
+func (suite *DiffTestSuite) TestMapKeysOnOneSide() {
+	a := Building{BuildingMap: map[string]string{"1": "1", "2": "2"}}
+	b := Building{BuildingMap: map[string]string{"2": "2", "3": "3"}}
+	differ := NewDiffer().Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	df, _ := differ.FindDiff("Building.BuildingMap[1]")
+	suite.Equal(NilDiff, df.Type())
+	suite.Equal(notNull, df.A())
+	suite.Equal(null, df.B())
+	df, _ = differ.FindDiff("Building.BuildingMap[3]")
+	suite.Equal(null, df.A())
+	suite.Equal(notNull, df.B())
+
+	suite.NotPanics(func() {
+		NewDiffer().Compare(map[string]*Person{"a": nil}, map[string]*Person{"b": {}})
+	})
+}
+
+func (suite *DiffTestSuite) TestPreset() {
+	const (
+		spec1 = `{"paths":{"/pets":{"get":{"tags":["a","b"],"x-internal":1}},"/users":{}},
+"components":{"schemas":{"Pet":{"required":["id"],"properties":{"id":{"type":"integer"},"name":{"type":"string"}}}}}}`
+		spec2 = `{"paths":{"/pets":{"get":{"tags":["b","a"],"x-internal":2}}},
+"components":{"schemas":{"Pet":{"required":["id"],"properties":{"id":{"type":"integer"},"name":{"type":"string"},"age":{"type":"integer"}}}}}}`
+	)
+	var s1, s2 interface{}
+	suite.NoError(json.Unmarshal([]byte(spec1), &s1))
+	suite.NoError(json.Unmarshal([]byte(spec2), &s2))
+	differ := NewDiffer().Compare(s1, s2, OpenAPIPreset.Option())
+	breaking, nonBreaking := OpenAPIPreset.Classify(differ.Result().Diffs)
+	suite.Len(breaking, 1)
+	suite.Equal("$[paths][/users]", breaking[0].Path())
+	suite.Len(nonBreaking, 3)
+
+	const (
+		schema1 = `{"properties":{"required":{"type":"boolean","description":"a"}}}`
+		schema2 = `{"properties":{"required":{"type":"boolean","description":"b"}}}`
+	)
+	suite.NoError(json.Unmarshal([]byte(schema1), &s1))
+	suite.NoError(json.Unmarshal([]byte(schema2), &s2))
+	breaking, nonBreaking = OpenAPIPreset.Classify(NewDiffer().Compare(s1, s2, OpenAPIPreset.Option()).Result().Diffs)
+	suite.Empty(breaking)
+	suite.Len(nonBreaking, 1)
+
+	const (
+		state1 = `{"serial":1,"resources":[{"depends_on":["a","b"],"values":{"depends_on":["x","y"]}}]}`
+		state2 = `{"serial":2,"resources":[{"depends_on":["b","a"],"values":{"depends_on":["y","x"]}}]}`
+	)
+	suite.NoError(json.Unmarshal([]byte(state1), &s1))
+	suite.NoError(json.Unmarshal([]byte(state2), &s2))
+	differ = NewDiffer().Compare(s1, s2, TerraformPreset.Option())
+	suite.Len(differ.Diffs(), 2)
+	_, ok := differ.FindDiff("$[resources][0][values][depends_on][0]")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestTypeDiffer() {
+	type UserV1 struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Age   int
+		Email string
+		Loc   *Location
+	}
+	type UserV2 struct {
+		Identity int    `json:"id"`
+		Name     string `json:"full_name"`
+		Age      int64
+		Phone    []string
+		Loc      *Building
+	}
+	differ := NewTypeDiffer().Compare(UserV1{}, reflect.TypeOf(UserV2{}))
+	suite.Len(differ.Diffs(), 8)
+	for _, path := range []string{"UserV1.ID[Name]", "UserV1.Name[Tag]", "UserV1.Age[Type]", "UserV1.Email",
+		"UserV1.Phone", "UserV1.Loc.Name", "UserV1.Loc.Province", "UserV1.Loc.BuildingMap"} {
+		_, ok := differ.FindDiff(path)
+		suite.True(ok, path)
+	}
+
+	type OrderV1 struct {
+		Billing  Location
+		Shipping Location
+	}
+	type OrderV2 struct {
+		Billing  Building
+		Shipping Building
+	}
+	differ = NewTypeDiffer().Compare(OrderV1{}, OrderV2{})
+	suite.Len(differ.Diffs(), 6)
+	for _, path := range []string{"OrderV1.Billing.BuildingMap", "OrderV1.Shipping.BuildingMap"} {
+		_, ok := differ.FindDiff(path)
+		suite.True(ok, path)
+	}
+	type GridV1 struct{ Cells [3]int }
+	type GridV2 struct{ Cells [4]int }
+	differ = NewTypeDiffer().Compare(GridV1{}, GridV2{})
+	df, ok := differ.FindDiff("GridV1.Cells[Type]")
+	suite.True(ok)
+	suite.Equal("[3]int", df.A())
+
+	differ = NewTypeDiffer().Compare(nil, GridV2{})
+	df, ok = differ.FindDiff("GridV2")
+	suite.True(ok)
+	suite.Equal(NilDiff, df.Type())
+	suite.False(NewTypeDiffer().Compare(nil, nil).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestMigration() {
+	type PersonV1 struct {
+		FullName string
+		Age      int
+	}
+	migrate := func(old interface{}) interface{} {
+		p := old.(*PersonV1)
+		return &Person{Name: p.FullName, Age: p.Age}
+	}
+	differ := NewDiffer().WithMigration(migrate).Compare(&PersonV1{FullName: "sjl", Age: 20}, &Person{Name: "sjl", Age: 21})
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Person.Age")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestWatcher() {
+	var age int32
+	loadA := func() (interface{}, error) { return &Person{Age: 20}, nil }
+	loadB := func() (interface{}, error) { return &Person{Age: int(atomic.AddInt32(&age, 10))}, nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(NewDiffer(), loadA, loadB, time.Millisecond)
+	go w.Run(ctx)
+
+	e := <-w.Events()
+	suite.Len(e.Appeared, 1)
+	e = <-w.Events()
+	suite.Len(e.Resolved, 1)
+	cancel()
+	for range w.Events() {
+	}
+}
+
+func (suite *DiffTestSuite) TestWaitUntilEqual() {
+	var age int32
+	loadA := func() (interface{}, error) { return &Person{Age: 30}, nil }
+	loadB := func() (interface{}, error) { return &Person{Age: int(atomic.AddInt32(&age, 10))}, nil }
+	suite.NoError(WaitUntilEqual(context.Background(), loadA, loadB, time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := WaitUntilEqual(ctx, loadA, loadB, time.Millisecond)
+	var nee *NotEqualError
+	suite.True(errors.As(err, &nee))
+	suite.True(errors.Is(err, context.DeadlineExceeded))
+	suite.Contains(nee.Report, "Person.Age")
+}
+
+func (suite *DiffTestSuite) TestRecover() {
+	type Payload struct {
+		Name  string
+		Extra interface{}
+	}
+	p1 := &Payload{Name: "a", Extra: 1}
+	p2 := &Payload{Name: "b", Extra: "2"}
+	suite.True(allowPanic(func() { NewDiffer().Compare(p1, p2) }))
+
+	differ := NewDiffer().WithRecover().Compare(p1, p2)
+	suite.Len(differ.Diffs(), 2)
+	df, _ := differ.FindDiff("Payload.Extra")
+	suite.Equal(UncomparableDiff, df.Type())
+	_, ok := differ.FindDiff("Payload.Name")
+	suite.True(ok)
+}
+
+type caseComparator struct{}
+
+var caseOnlyDiff = RegisterDiffType("CaseOnlyDiff")
+
+func (*caseComparator) Match(path string) bool {
+	return path == "Person.Name"
+}
+
+func (*caseComparator) Equals(a, b interface{}) (dt DiffType, msgA, msgB interface{}) {
+	sa, sb := a.(string), b.(string)
+	if sa == sb {
+		return NoDiff, nil, nil
+	}
+	if strings.EqualFold(sa, sb) {
+		return caseOnlyDiff, sa, sb
+	}
+	return ElemDiff, sa, sb
+}
+
+func (suite *DiffTestSuite) TestCustomDiffType() {
+	suite.Equal(caseOnlyDiff, RegisterDiffType("CaseOnlyDiff"))
+	suite.Equal("ElemDiff", ElemDiff.String())
+	differ := NewDiffer().WithComparator(new(caseComparator)).Compare(&Person{Name: "SJL"}, &Person{Name: "sjl"})
+	df, ok := differ.FindDiff("Person.Name")
+	suite.True(ok)
+	suite.Equal(caseOnlyDiff, df.Type())
+	data, err := json.Marshal(df)
+	suite.NoError(err)
+	suite.JSONEq(`{"path":"Person.Name","type":"CaseOnlyDiff","a":"SJL","b":"sjl","byComparator":true}`, string(data))
+
+	var dt DiffType
+	suite.NoError(json.Unmarshal([]byte(`"CaseOnlyDiff"`), &dt))
+	suite.Equal(caseOnlyDiff, dt)
+}
+
+func (suite *DiffTestSuite) TestMissingElements() {
+	me := &Person{StrArr: []string{"hello", "world", strings.Repeat("x", 100)}}
+	he := &Person{StrArr: []string{"hello"}}
+	differ := NewDiffer().Compare(me, he)
+	suite.Len(differ.Diffs(), 3)
+	df, ok := differ.FindDiff("Person.StrArr[1]")
+	suite.True(ok)
+	suite.Equal(MissingDiff, df.Type())
+	suite.Equal("world", df.A())
+	suite.Equal(missing, df.B())
+	df, _ = differ.FindDiff("Person.StrArr[2]")
+	suite.Equal(strings.Repeat("x", maxMissingValueLen)+"...", df.A())
+
+	df, ok = NewDiffer().Compare(he, me).FindDiff("Person.StrArr[1]")
+	suite.True(ok)
+	suite.Equal(missing, df.A())
+	suite.Equal("world", df.B())
+}
+
+func (suite *DiffTestSuite) TestRelativeTolerance() {
+	type Metric struct {
+		Small float64
+		Large int64
+	}
+	a := Metric{Small: 0.100, Large: 1000000}
+	b := Metric{Small: 0.10005, Large: 1009000}
+	suite.False(NewDiffer().WithRelativeTolerance(`Metric\.`, 0.01).Compare(a, b).HasDiffs())
+
+	differ := NewDiffer().Compare(a, b, OptRelativeTolerance(`Metric\.`, 0.001))
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Metric.Large")
+	suite.True(ok)
+
+	suite.True(NewDiffer().WithTolerance(`Metric\.`, 0.01).Compare(a, b).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestDurationAndUnits() {
+	type Job struct {
+		Timeout  time.Duration
+		Interval string
+		Ratio    string
+	}
+	a := Job{Timeout: 5 * time.Second, Interval: "1m", Ratio: "50%"}
+	b := Job{Timeout: 5*time.Second + 3*time.Millisecond, Interval: "60001ms", Ratio: "0.5"}
+
+	differ := NewDiffer().Compare(a, b)
+	suite.Len(differ.Diffs(), 3)
+	df, _ := differ.FindDiff("Job.Timeout")
+	suite.Equal("5s", fmt.Sprint(df.A()))
+	suite.Equal("5.003s", fmt.Sprint(df.B()))
+
+	percent := func(s string) (float64, bool) {
+		s = strings.TrimSpace(s)
+		if strings.HasSuffix(s, "%") {
+			f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+			return f / 100, err == nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	differ = NewDiffer().WithUnitAware(`Interval`, `Ratio`).WithUnitNormalizer(percent).
+		WithDurationTolerance(`Timeout|Interval`, 10*time.Millisecond).Compare(a, b)
+	suite.False(differ.HasDiffs(), differ.String())
+
+	type Retry struct {
+		Timeout  time.Duration
+		Attempts int
+	}
+	differ = NewDiffer().WithDurationTolerance(`.*`, 10*time.Millisecond).
+		Compare(Retry{time.Second, 1}, Retry{time.Second + time.Millisecond, 3})
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Retry.Attempts")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestDateFormat() {
+	type Order struct {
+		CreatedAt string
+		PaidAt    string
+	}
+	a := Order{CreatedAt: "2024-01-02", PaidAt: "2024-01-03T10:00:00Z"}
+	b := Order{CreatedAt: "02 Jan 2024", PaidAt: "2024-01-03T18:00:00+08:00"}
+	suite.Len(NewDiffer().Compare(a, b).Diffs(), 2)
+
+	layouts := []string{"2006-01-02", "02 Jan 2006", time.RFC3339}
+	suite.False(NewDiffer().WithDateFormat(`At$`, layouts...).Compare(a, b).HasDiffs())
+
+	b.PaidAt = "2024-01-04T10:00:00Z"
+	differ := NewDiffer().Compare(a, b, OptDateFormat(`At$`, layouts...))
+	suite.Len(differ.Diffs(), 1)
+	df, ok := differ.FindDiff("Order.PaidAt")
+	suite.True(ok)
+	suite.Equal("2024-01-03T10:00:00Z", df.A())
+
+	suite.Panics(func() { NewDiffer().WithDateFormat(`At$`) })
+}
+
+func (suite *DiffTestSuite) TestMoneyComparator() {
+	type Invoice struct {
+		Total string
+		Paid  float64
+		Cents int64
+	}
+	a := Invoice{Total: "$1,234.50", Paid: 12.3, Cents: 123450}
+	b := Invoice{Total: "1234.5", Paid: 12.30, Cents: 1234}
+	differ := NewDiffer().WithComparator(NewMoneyComparator(`Total|Paid`)).Compare(a, b)
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Invoice.Cents")
+	suite.True(ok)
+
+	type Payment struct {
+		Amount interface{}
+	}
+	mc := NewMoneyComparator(`Amount`).WithScale(2, 0)
+	suite.False(NewDiffer().WithComparator(mc).Compare(Payment{123450}, Payment{"1,234.50"}).HasDiffs())
+	differ = NewDiffer().WithComparator(mc).Compare(Payment{123450}, Payment{"1,234.51"})
+	df, ok := differ.FindDiff("Payment.Amount")
+	suite.True(ok)
+	suite.True(df.ByComparator())
+	suite.Equal("1,234.51", df.B())
+	_, ok = NewDiffer().WithComparatorSuffix().WithComparator(mc).
+		Compare(Payment{123450}, Payment{"1,234.51"}).FindDiff("Payment.Amount" + useComparatorSuffix)
+	suite.True(ok)
+	suite.True(NewDiffer().WithComparator(mc).Compare(Payment{"N/A"}, Payment{"n/a"}).HasDiffs())
+}
+
+type strictComparator struct{}
+
+var errNotString = errors.New("not a string")
+
+func (*strictComparator) Match(fieldPath string) bool {
+	return strings.HasSuffix(fieldPath, ".Extra") || strings.HasSuffix(fieldPath, ".Code")
+}
+
+func (*strictComparator) Equals(a, b interface{}) (DiffType, interface{}, interface{}) {
+	return DiffType(42), a, b
+}
+
+func (*strictComparator) EqualsE(a, b interface{}) (DiffType, interface{}, interface{}, error) {
+	if _, ok := a.(string); !ok {
+		return NoDiff, nil, nil, errNotString
+	}
+	if a == b {
+		return NoDiff, nil, nil, nil
+	}
+	return ElemDiff, a, b, nil
+}
+
+type badComparator struct {
+	strictComparator
+}
+
+func (*badComparator) EqualsE(a, b interface{}) (DiffType, interface{}, interface{}, error) {
+	return DiffType(42), a, b, nil
+}
+
+func (suite *DiffTestSuite) TestComparatorErrors() {
+	type Payload struct {
+		Extra interface{}
+		Code  string
+	}
+	a := Payload{Extra: 1, Code: "a"}
+	b := Payload{Extra: 1, Code: "b"}
+	differ := NewDiffer().WithComparator(new(strictComparator)).Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	df, _ := differ.FindDiff("Payload.Extra")
+	suite.Equal(UncomparableDiff, df.Type())
+	suite.Len(differ.Errors(), 1)
+	suite.Equal("Payload.Extra", differ.Errors()[0].Path)
+
+	err := NewDiffer().WithComparator(new(strictComparator)).CompareE(a, b)
+	suite.True(errors.Is(err, errNotString))
+	var ce *CompareError
+	suite.True(errors.As(err, &ce))
+
+	differ = NewDiffer().WithComparator(new(badComparator)).Compare(a, b)
+	suite.Len(differ.Errors(), 2)
+	suite.Contains(differ.Errors()[1].Error(), "unexpected DiffType")
+}
+
+func (suite *DiffTestSuite) TestPanicContext() {
+	type Payload struct {
+		Extra interface{}
+	}
+	suite.PanicsWithValue("unexpected interface at Payload.Extra (depth 1): "+
+		"A is interface {}(int), B is interface {}(string)", func() {
+		NewDiffer().Compare(Payload{Extra: 1}, Payload{Extra: "1"})
+	})
+	me := &Person{Parents: []*Person{{Parents: []*Person{{}}}}}
+	he := &Person{Parents: []*Person{{Parents: []*Person{{}}}}}
+	suite.PanicsWithValue("depth over limit at Person.Parents[0].Parents[0].Name (depth 3): "+
+		"A is string, B is string", func() {
+		NewDiffer().WithMaxDepth(2).Compare(me, he)
+	})
+}
+
+func (suite *DiffTestSuite) TestDryRun() {
+	differ := NewDiffer().Ignore(`Person\.Age`, `Person\.Nickname`).WithTrimSpace(`\.Name$`).
+		WithComparator(&nameComparator{}).WithMaxDepth(3)
+	report := differ.DryRun(&Person{})
+	suite.Contains(report.Paths, "Person.Parents[0].Name")
+	suite.NotContains(report.Paths, "Person.Parents[0].Loc.Name")
+	suite.Len(report.Rules, 2)
+	suite.Equal([]string{"Person.Age"}, report.Rules[0].Paths)
+	suite.Equal("trimSpace", report.Rules[1].Kind)
+	suite.Len(report.Dead, 2)
+	suite.Equal(`Person\.Nickname`, report.Dead[0].Rule)
+	suite.Equal("comparator", report.Dead[1].Kind)
+	suite.Contains(report.String(), "ignore Person\\.Nickname matches nothing")
+
+	suite.Contains(NewDiffer().DryRun(Building{}).Paths, "Building.BuildingMap[*]")
+
+	report = NewDiffer().Ignore(`\.L$`).DryRun(&treeNode{})
+	suite.Len(report.Paths, 1+3+9)
+	suite.Equal([]string{"treeNode.L", "treeNode.L.L", "treeNode.R.L", "treeNode.M.L"}, report.Rules[0].Paths)
+}
+
+func (suite *DiffTestSuite) TestPaths() {
+	suite.Equal([]string{"Location", "Location.Name", "Location.Province"}, Paths(Location{}, OptMaxDepth(1)))
+	suite.Equal([]string{"$", "$[*]"}, Paths(map[string]int{}))
+	suite.Contains(Paths(&Person{}), "Person.Parents[0].Loc.Province.Name")
+	suite.Nil(Paths(nil))
+	suite.Equal([]string{"recursiveMap", "recursiveMap[*]", "recursiveMap[*][*]"}, Paths(recursiveMap{}, OptMaxDepth(2)))
+	suite.Equal([]string{"recursiveSlice", "recursiveSlice[0]", "recursiveSlice[0][0]"}, Paths(recursiveSlice{}, OptMaxDepth(2)))
+	paths := Paths(treeNode{})
+	suite.Len(paths, 1+3+9)
+	suite.Contains(paths, "treeNode.L.R")
+	suite.NotContains(paths, "treeNode.L.R.M")
+}
+
+type (
+	recursiveMap   map[string]recursiveMap
+	recursiveSlice []recursiveSlice
+	treeNode       struct{ L, R, M *treeNode }
+)
+
+type testSpan struct {
+	parent *testSpan
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(name string, parent Span) Span {
+	s := &testSpan{attrs: map[string]interface{}{}}
+	if parent != nil {
+		s.parent = parent.(*testSpan)
+	}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func (suite *DiffTestSuite) TestTracer() {
+	me := &Person{Name: "sjl", Parents: []*Person{{Name: "a"}}}
+	he := &Person{Name: "kxc", Parents: []*Person{{Name: "b"}}}
+	tracer := &testTracer{}
+	NewDiffer().WithTracer(tracer, `^Person\.Parents$`).Compare(me, he)
+	suite.Len(tracer.spans, 2)
+	root, child := tracer.spans[0], tracer.spans[1]
+	suite.True(root.ended)
+	suite.True(child.ended)
+	suite.Nil(root.parent)
+	suite.Equal(root, child.parent)
+	suite.Equal("Person", root.attrs[traceAttrPath])
+	suite.Equal(2, root.attrs[traceAttrDiffs])
+	suite.Equal(1, child.attrs[traceAttrDiffs])
+	suite.Equal("Person.Parents", child.attrs[traceAttrPath])
+	suite.True(root.attrs[traceAttrNodes].(int) > child.attrs[traceAttrNodes].(int))
+}
+
+func (suite *DiffTestSuite) TestSampler() {
+	me := &Person{Name: "sjl"}
+	he := &Person{Name: "kxc"}
+	differ := NewDiffer()
+	res, ok := NewSampler(differ, 1).Compare(me, he)
+	suite.True(ok)
+	suite.True(res.HasDiffs())
+	suite.False(differ.HasDiffs())
+
+	sampler := NewSampler(differ, 0)
+	_, ok = sampler.Compare(me, he)
+	suite.False(ok)
+	suite.Equal(uint64(1), sampler.Skipped())
+
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	sampler = NewSampler(differ, 1)
+	sampler.now = func() time.Time { return now }
+	sampler.WithRateLimit(1, 2)
+	for i := 0; i < 5; i++ {
+		sampler.Compare(me, he)
+	}
+	suite.Equal(uint64(2), sampler.Compared())
+	suite.Equal(uint64(3), sampler.Skipped())
+	now = now.Add(time.Second)
+	_, ok = sampler.Compare(me, he)
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestQueue() {
+	var diffs, failures int64
+	q := NewQueue(NewDiffer(), 2, 8, func(p Pair, res *Result, err error) {
+		if err != nil {
+			atomic.AddInt64(&failures, 1)
+			return
+		}
+		atomic.AddInt64(&diffs, int64(len(res.Diffs)))
+	})
+	for i := 0; i < 4; i++ {
+		suite.NoError(q.SubmitWait(context.Background(), &Person{Age: i}, &Person{Age: i + 1}))
+	}
+	suite.NoError(q.Submit(&Person{}, &Location{}))
+	q.Close()
+	suite.Equal(int64(4), diffs)
+	suite.Equal(int64(1), failures)
+	suite.Equal(uint64(5), q.Submitted())
+	suite.Equal(ErrQueueClosed, q.Submit(1, 2))
+
+	block := make(chan struct{})
+	q = NewQueue(NewDiffer(), 1, 1, func(p Pair, res *Result, err error) { <-block })
+	suite.NoError(q.Submit(1, 2))
+	for q.Len() > 0 {
+		runtime.Gosched()
+	}
+	suite.NoError(q.Submit(1, 2))
+	suite.Equal(ErrQueueFull, q.Submit(1, 2))
+	suite.Equal(uint64(1), q.Rejected())
+	close(block)
+	q.Close()
+}
+
+type testProducer struct {
+	topic      string
+	key, value []byte
+}
+
+func (p *testProducer) Produce(topic string, key, value []byte) error {
+	p.topic, p.key, p.value = topic, key, value
+	return nil
+}
+
+func (suite *DiffTestSuite) TestSinks() {
+	me := &Person{Name: "sjl"}
+	he := &Person{Name: "kxc"}
+	buf := &bytes.Buffer{}
+	var posted []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	producer := &testProducer{}
+	differ := NewDiffer().WithLabel("shadow").
+		WithSink(JSONLinesSink(buf), WebhookSink(server.URL, nil), KafkaSink(producer, "diffs"))
+	differ.Compare(me, he)
+	suite.NoError(differ.SinkErr())
+	line := `{"label":"shadow","diffs":[{"path":"Person.Name","type":"ElemDiff","a":"sjl","b":"kxc"}]}`
+	suite.Equal(line+"\n", buf.String())
+	suite.JSONEq(line, string(posted))
+	suite.Equal("diffs", producer.topic)
+	suite.Equal("shadow", string(producer.key))
+	suite.JSONEq(line, string(producer.value))
+
+	errSink := errors.New("sink")
+	differ = NewDiffer().WithSink(SinkFunc(func(res *Result) error { return errSink }))
+	suite.Equal(errSink, differ.Compare(me, he).SinkErr())
+	suite.Error(NewDiffer().WithSink(WebhookSink(server.URL+"/404", &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 404, Status: "404 Not Found", Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+		}),
+	})).Compare(me, he).SinkErr())
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+func (suite *DiffTestSuite) TestAlerter() {
+	var alerts []Alert
+	severity := func(df *Diff) Severity {
+		if strings.HasSuffix(df.Path(), ".Age") {
+			return SeverityHigh
+		}
+		return SeverityLow
+	}
+	alerter := NewAlerter(severity, func(alert Alert) { alerts = append(alerts, alert) }).
+		AddRule(AlertRule{Name: "age", MinSeverity: SeverityHigh, MaxRatio: 0.3, Window: time.Minute})
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	alerter.now = func() time.Time { return now }
+	me := &Person{Name: "sjl", Age: 20}
+	compare := func(he *Person) {
+		NewDiffer().WithSink(alerter).Compare(me, he)
+	}
+	for i := 0; i < 3; i++ {
+		compare(&Person{Name: "kxc", Age: 20})
+	}
+	suite.Empty(alerts)
+	compare(&Person{Name: "sjl", Age: 21})
+	suite.Empty(alerts)
+	compare(&Person{Name: "sjl", Age: 22})
+	suite.Len(alerts, 1)
+	suite.Equal(2, alerts[0].Count)
+	suite.Equal(5, alerts[0].Total)
+	suite.Equal("age", alerts[0].Rule.Name)
+
+	compare(&Person{Name: "sjl", Age: 23})
+	suite.Len(alerts, 1)
+	now = now.Add(2 * time.Minute)
+	compare(&Person{Name: "sjl", Age: 23})
+	suite.Len(alerts, 2)
+	suite.Equal(1, alerts[1].Total)
+}
+
+func (suite *DiffTestSuite) TestCloneAndMerge() {
+	me := &Person{Name: "sjl", Age: 20, Loc: newLoc("JiAn")}
+	he := &Person{Name: "kxc", Age: 21, Loc: newLoc("JiangXi")}
+	base := NewDiffer().Ignore(`Person\.Age`)
+	clone := base.Clone()
+	clone.ignores = append(clone.ignores, regexp.MustCompile(`Person\.Name`))
+	suite.Len(base.ignores, 1)
+	suite.Len(base.Compare(me, he).Diffs(), 2)
+	suite.Len(clone.Compare(me, he).Diffs(), 1)
+
+	other := NewDiffer().Ignore(`Person\.Loc`).WithLabel("merged").WithMaxDepth(5)
+	merged := base.Clone().Merge(other)
+	suite.Len(merged.Compare(me, he).Diffs(), 1)
+	suite.Equal("merged", merged.Label())
+	suite.Equal(5, merged.maxDepth)
+	suite.Empty(base.Clone().Diffs())
+	suite.Len(base.ignores, 1)
+}
+
+func (suite *DiffTestSuite) TestPathStyle() {
+	a := &order{Total: 3, Items: []orderItem{{1}, {2}}}
+	b := &order{Total: 4, Items: []orderItem{{1}, {2.5}}}
+	differ := NewDiffer().WithRootName("a").Compare(a, b)
+	suite.Equal("Field: \"a.Items[1].Price\", A: 2, B: 2.5\n"+
+		"Field: \"a.Total\", A: 3, B: 4\n", differ.String())
+	_, ok := differ.FindDiff("a.Total")
+	suite.True(ok)
+	_, ok = differ.FindDiff("order.Total")
+	suite.True(ok)
+
+	differ = NewDiffer().Compare(a, b, OptRootName("$"), OptPathSeparator(".", ".", ""))
+	suite.Equal("Field: \"$.Items.1.Price\", A: 2, B: 2.5\n"+
+		"Field: \"$.Total\", A: 3, B: 4\n", differ.String())
+
+	differ = NewDiffer().Ignore(`^order\.Total$`).WithRootName("").Compare(a, b)
+	suite.Equal("Field: \"Items[1].Price\", A: 2, B: 2.5\n", differ.String())
+	differ = NewDiffer().WithRootName("").WithPathSeparator("/", "/", "").Compare([]int{1}, []int{2})
+	suite.Equal("Field: \"0\", A: 1, B: 2\n", differ.String())
+}
+
+func (suite *DiffTestSuite) TestFingerprint() {
+	p1, p2 := &Person{Name: "p1", Age: 40}, &Person{Name: "p2", Age: 50}
+	me := &Person{Name: " sjl ", Age: 20, StrArr: []string{"a"}, Parents: []*Person{p2, p1}}
+	he := &Person{Name: "sjl", Age: 21, StrArr: []string{"b"}, Parents: []*Person{p1, p2}}
+	suite.NotEqual(Fingerprint(me), Fingerprint(he))
+	differ := NewDiffer().Ignore(`StrArr`).WithTrimSpace(`Person\.Name`).WithTolerance(`Person\.Age`, 5).
+		WithSorter(&pSorter{regexp.MustCompile("Person.Parents")})
+	suite.Equal(differ.Fingerprint(me), differ.Fingerprint(he))
+	suite.False(differ.Compare(me, he).HasDiffs())
+	suite.Equal(Fingerprint(me, OptIgnore(`Age`)), Fingerprint(&Person{Name: " sjl ", StrArr: []string{"a"}, Parents: []*Person{p2, p1}}, OptIgnore(`Age`)))
+	suite.NotEqual(Fingerprint(map[string]int{"a": 1}), Fingerprint(map[string]int{"a": 2}))
+	suite.Equal(Fingerprint(map[string]int{"a": 1, "b": 2}), Fingerprint(map[string]int{"b": 2, "a": 1}))
+	suite.Equal(Fingerprint(nil), Fingerprint(nil))
+}
+
+func (suite *DiffTestSuite) TestNormalize() {
+	p1, p2 := &Person{Name: "p1", Age: 41}, &Person{Name: "p2", Age: 52}
+	me := &Person{Name: " sjl ", Age: 22, StrArr: []string{"a"}, Parents: []*Person{p2, p1}}
+	differ := NewDiffer().Ignore(`StrArr`).WithTrimSpace(`Name`).WithTolerance(`Age`, 5).
+		WithSorter(&pSorter{regexp.MustCompile("Person.Parents")}).WithNilExpansion()
+	n := differ.Normalize(me).(*Person)
+	suite.Equal(&Person{Name: "sjl", Age: 20, Loc: &Location{},
+		Parents: []*Person{
+			{Name: "p1", Age: 40, Loc: &Location{}, Parents: []*Person{}},
+			{Name: "p2", Age: 50, Loc: &Location{}, Parents: []*Person{}},
+		}}, n)
+	suite.Equal(" sjl ", me.Name)
+	suite.Equal(p2, me.Parents[0])
+	suite.Equal(map[string]string{"a": "1.5"}, Normalize(map[string]string{"a": "1.46"}, OptTolerance(`a`, 0.5)))
+	suite.Equal(sql.NullInt64{Valid: true}, Normalize(sql.NullInt64{}, func(d *Differ) { d.WithNullAsZero(`NullInt64`) }))
+	suite.Nil(Normalize(nil))
+	me.Loc = &Location{Name: "JiAn", Province: newLoc("JiangXi")}
+	suite.Equal(&Person{Name: " sjl ", Age: 22}, Normalize(me, OptIgnore(`\.Loc$`, `StrArr$`, `Parents$`)))
+}
+
+func (suite *DiffTestSuite) TestPrimitiveFastPath() {
+	type prims struct {
+		S string
+		B bool
+		I int8
+		U uint16
+		F float32
+		C complex64
+	}
+	a := prims{"a", true, 1, 2, 1.5, 1 + 2i}
+	suite.False(NewDiffer().Compare(a, a).HasDiffs())
+	differ := NewDiffer().Compare(a, prims{"b", false, 2, 3, 2.5, 2 + 2i})
+	suite.Len(differ.Diffs(), 6)
+	suite.True(primitiveEqual(reflect.ValueOf("x"), reflect.ValueOf("x")))
+	suite.False(primitiveEqual(reflect.ValueOf(math.NaN()), reflect.ValueOf(math.NaN())))
+	allocs := testing.AllocsPerRun(100, func() {
+		primitiveEqual(reflect.ValueOf(&a).Elem().Field(0), reflect.ValueOf(&a).Elem().Field(0))
+	})
+	suite.Zero(allocs)
+}
+
+func (suite *DiffTestSuite) TestPrimitiveDiffValues() {
+	type prims struct {
+		D time.Duration
+		U uint8
+		S string
+		B bool
+		F float32
+		p int
+	}
+	a := prims{time.Second, 1, "a", true, 1.5, 1}
+	b := prims{2 * time.Second, 2, "b", false, 2.5, 2}
+	differ := NewDiffer().Compare(a, b)
+	suite.Len(differ.Diffs(), 6)
+	df, ok := differ.FindDiff("prims.D")
+	suite.True(ok)
+	suite.NotNil(df.pa.typ)
+	suite.Nil(df.va)
+	suite.Equal(time.Second, df.A())
+	suite.Equal(2*time.Second, df.Vb())
+	suite.Equal(`Field: "prims.D", A: 1s, B: 2s`, df.String())
+	df, _ = differ.FindDiff("prims.U")
+	suite.Equal(uint8(1), df.A())
+	df, _ = differ.FindDiff("prims.B")
+	suite.Equal(false, df.B())
+	df, _ = differ.FindDiff("prims.F")
+	suite.Equal(float32(2.5), df.B())
+	df, _ = differ.FindDiff("prims.p")
+	suite.Nil(df.pa.typ)
+
+	redacted := NewDiffer().WithRedact(`\.S$`).Compare(a, b)
+	df, _ = redacted.FindDiff("prims.S")
+	suite.Nil(df.pa.typ)
+	suite.Equal(`Field: "prims.S", A: <redacted>, B: <redacted>`, df.String())
+}
+
+func (suite *DiffTestSuite) TestSorterRecover() {
+	type bag struct {
+		Items []interface{}
+	}
+	a := bag{Items: []interface{}{"b", "a"}}
+	b := bag{Items: []interface{}{"b", "c"}}
+	sorter := &pSorter{regexp.MustCompile(`^bag\.Items$`)}
+	suite.PanicsWithError(`sorter failed at bag.Items: Less(b, a) panics: interface conversion: interface {} is string, not *sdiffer.Person`, func() {
+		NewDiffer().WithSorter(sorter).Compare(a, b)
+	})
+
+	differ := NewDiffer().WithSorter(sorter).WithSorterRecover().Compare(a, b)
+	df, ok := differ.FindDiff("bag.Items")
+	suite.True(ok)
+	suite.Equal(SorterDiff, df.Type())
+	suite.Contains(df.A(), "sorter failed at bag.Items")
+	_, ok = differ.FindDiff("bag.Items[1]")
+	suite.True(ok)
+	suite.Len(differ.Diffs(), 2)
+}
+
+func (suite *DiffTestSuite) TestMapSorter() {
+	type config struct {
+		Precedence map[int]string
+	}
+	a := config{map[int]string{1: "flag", 2: "env", 10: "file"}}
+	b := config{map[int]string{10: "flag", 20: "env", 30: "file", 40: "default"}}
+	differ := NewDiffer().WithMapSorter(KeyOrder(`\.Precedence$`)).Compare(a, b)
+	suite.Equal(`Field: "config.Precedence[0].Key", A: 1, B: 10
+Field: "config.Precedence[1].Key", A: 2, B: 20
+Field: "config.Precedence[2].Key", A: 10, B: 30
+Field: "config.Precedence[3]", A: <missing>, B: 40: default
+Field: "config.Precedence[Length]", A: 3, B: 4
+`, differ.Render(""))
+
+	byValue := MapSorterFunc(`\.Precedence$`, func(x, y MapEntry) bool {
+		return x.Value.(string) < y.Value.(string)
+	})
+	b = config{map[int]string{3: "env", 2: "file", 1: "flag"}}
+	differ = NewDiffer().WithMapSorter(byValue).Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	_, ok := differ.FindDiff("config.Precedence[0].Key")
+	suite.True(ok)
+
+	panicking := MapSorterFunc(`\.Precedence$`, func(x, y MapEntry) bool {
+		return x.Value.(int) < y.Value.(int)
+	})
+	suite.Panics(func() {
+		NewDiffer().WithMapSorter(panicking).Compare(a, b)
+	})
+	differ = NewDiffer().WithMapSorter(panicking).WithSorterRecover().Compare(a, b)
+	df, _ := differ.FindDiff("config.Precedence")
+	suite.Equal(SorterDiff, df.Type())
+	_, ok = differ.FindDiff("config.Precedence[2]")
+	suite.True(ok)
+}
+
+var paddedLabels = []string{" x ", "x", "y"}
+
+type paddedLabel int
+
+func (l paddedLabel) String() string {
+	return paddedLabels[l]
+}
+
+type constantStringer struct {
+	Amount   int
+	Currency string
+}
+
+func (constantStringer) String() string {
+	return "money"
+}
+
+func (suite *DiffTestSuite) TestTrimConvertibles() {
+	type doc struct {
+		Label paddedLabel
+		Body  []byte
+		Tags  map[string]int
+	}
+	a := doc{0, []byte("body\n"), map[string]int{" a": 1, "b": 2}}
+	b := doc{1, []byte("body"), map[string]int{"a": 1, "b": 3}}
+	suite.Len(NewDiffer().Compare(a, b).Diffs(), 6)
+	differ := NewDiffer().WithTrimSpace(`Label$`, `Body$`, `Tags$`).Compare(a, b)
+	suite.Equal("Field: \"doc.Tags[b]\", A: 2, B: 3\n", differ.Render(""))
+	differ = NewDiffer().WithTrim(`Body$`, "\n").Compare(a, b)
+	_, ok := differ.FindDiff("doc.Body")
+	suite.False(ok)
+	_, ok = differ.FindDiff("doc.Label")
+	suite.True(ok)
+
+	a.Label, b.Label = 0, 2
+	_, ok = NewDiffer().WithTrimSpace(`Label$`).Compare(a, b).FindDiff("doc.Label")
+	suite.True(ok)
+
+	differ = NewDiffer().WithTrimSpace(`.*`).Compare(constantStringer{1, "CNY"}, constantStringer{2, "USD"})
+	suite.Len(differ.Diffs(), 2)
+}
+
+func (suite *DiffTestSuite) TestStringPosition() {
+	type order struct {
+		ID   string
+		Note string
+		Qty  int
+	}
+	a := order{"3f2a9c1e-4f1c-9a0B-77e1-0c9d2b7e5a10", "ok", 1}
+	b := order{"3f2a9c1e-4f1c-9a08-77e1-0c9d2b7e5a10", "okay", 2}
+	differ := NewDiffer().WithStringPosition().Compare(a, b)
+	suite.Equal(`Field: "order.ID", A: 3f2a9c1e-4f1c-9a0B-77e1-0c9d2b7e5a10, B: 3f2a9c1e-4f1c-9a08-77e1-0c9d2b7e5a10 (at rune 17: "...e-4f1c-9a0B-77e1-0c9d..." vs "...e-4f1c-9a08-77e1-0c9d...")
+Field: "order.Note", A: ok, B: okay (at rune 2: "ok" vs "okay")
+Field: "order.Qty", A: 1, B: 2
+`, differ.Render(""))
+	df, _ := differ.FindDiff("order.Note")
+	pos, ok := df.StringPosition()
+	suite.True(ok)
+	suite.Equal(StringPosition{Index: 2, ExcerptA: "ok", ExcerptB: "okay"}, pos)
+	df, _ = differ.FindDiff("order.Qty")
+	_, ok = df.StringPosition()
+	suite.False(ok)
+	suite.NotContains(NewDiffer().Compare(a, b).Render(""), "at rune")
+}
+
+func (suite *DiffTestSuite) TestErrorComparison() {
+	type call struct {
+		Err  error
+		Last error
+	}
+	errNotFound := errors.New("not found")
+	a := call{fmt.Errorf("get user 1: %w", errNotFound), errors.New("timeout")}
+	b := call{fmt.Errorf("get user 2: %w", errNotFound), errors.New("timeout")}
+	differ := NewDiffer().WithErrorComparison(ErrorByMessage).Compare(a, b)
+	suite.Equal("Field: \"call.Err\", A: get user 1: not found, B: get user 2: not found\n", differ.Render(""))
+	differ = NewDiffer().WithErrorComparison(ErrorByChain).Compare(a, b)
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("call.Last")
+	suite.True(ok)
+
+	b.Err = fmt.Errorf("get user 1: %w", context.Canceled)
+	differ = NewDiffer().WithErrorComparison(ErrorByChain, `\.Err$`).Compare(a, b)
+	suite.Equal("Field: \"call.Err\", A: get user 1: not found, B: get user 1: context canceled\n", differ.Render(""))
+
+	b.Err = nil
+	differ = NewDiffer().WithErrorComparison(ErrorByMessage).Compare(a, b)
+	df, ok := differ.FindDiff("call.Err")
+	suite.True(ok)
+	suite.Equal(NilDiff, df.Type())
+
+	var typedNil *codedError
+	a.Err, b.Err = &codedError{404}, typedNil
+	differ = NewDiffer().WithErrorComparison(ErrorByMessage).Compare(a, b)
+	df, ok = differ.FindDiff("call.Err")
+	suite.True(ok)
+	suite.Equal(NilDiff, df.Type())
+	a.Err = typedNil
+	suite.False(NewDiffer().WithErrorComparison(ErrorByMessage).Compare(a, b).HasDiffs())
+}
+
+type codedError struct {
+	code int
+}
+
+func (e *codedError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+type syncCounter struct {
+	Mu      sync.Mutex
+	Wg      *sync.WaitGroup
+	Value   atomic.Value
+	last    atomic.Value
+	Entries sync.Map
+}
+
+func (suite *DiffTestSuite) TestSyncTypes() {
+	a, b := &syncCounter{Wg: &sync.WaitGroup{}}, &syncCounter{}
+	a.Mu.Lock()
+	defer a.Mu.Unlock()
+	a.Wg.Add(1)
+	a.Value.Store(1)
+	b.Value.Store(2)
+	a.last.Store("x")
+	b.last.Store("x")
+	a.Entries.Store("k", 1)
+	b.Entries.Store("k", 2)
+	differ := NewDiffer().Compare(a, b)
+	suite.Equal(`Field: "syncCounter.Value", A: 1, B: 2
+Field: "syncCounter.Wg", A: <not nil>, B: <nil>
+`, differ.Render(""))
+
+	b.last.Store("y")
+	differ = NewDiffer().WithSyncMapSnapshot(`\.Entries$`).Compare(a, b)
+	suite.Len(differ.Diffs(), 4)
+	df, ok := differ.FindDiff("syncCounter.Entries[k]")
+	suite.True(ok)
+	suite.Equal(2, df.B())
+	_, ok = differ.FindDiff("syncCounter.last")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestInfraTypes() {
+	type job struct {
+		Name   string
+		Ctx    context.Context
+		Body   io.ReadCloser
+		Client *http.Client
+		Done   chan struct{}
+		Err    error
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := job{"a", ctx, ioutil.NopCloser(strings.NewReader("x")), http.DefaultClient, make(chan struct{}), nil}
+	b := job{"b", context.Background(), nil, &http.Client{}, nil, errors.New("e")}
+	differ := NewDiffer().Compare(a, b)
+	suite.Equal("Field: \"job.Err\", A: <nil>, B: <not nil>\nField: \"job.Name\", A: a, B: b\n", differ.Render(""))
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	differ = NewDiffer().WithSkippedTypes(errorType).Compare(a, b)
+	suite.Len(differ.Diffs(), 1)
+	b.Ctx = ctx
+	differ = NewDiffer().WithInfraComparison().Compare(a, b)
+	_, ok := differ.FindDiff("job.Body")
+	suite.True(ok)
+	_, ok = differ.FindDiff("job.Done")
+	suite.True(ok)
+}
+
+func (suite *DiffTestSuite) TestScopedIgnores() {
+	type blob struct {
+		Kind  string
+		Items []*Location
+		Meta  map[string]interface{}
+	}
+	type service struct {
+		Name  string
+		Blob  blob
+		Cache struct {
+			Size  int
+			Owner *Person
+			Hook  func()
+		}
+	}
+	a, b := &service{Name: "a"}, &service{Name: "b"}
+	a.Blob = blob{"x", []*Location{newLoc("JiAn")}, map[string]interface{}{"k": map[string]int{"v": 1}}}
+	b.Blob = blob{"y", []*Location{newLoc("JiangXi"), nil}, map[string]interface{}{"k": map[string]int{"v": 2}}}
+	a.Cache.Size, b.Cache.Size = 1, 2
+	a.Cache.Owner, b.Cache.Owner = &Person{Name: "sjl"}, &Person{Name: "kxc"}
+	a.Cache.Hook = func() {}
+
+	differ := NewDiffer().IgnoreBelow(`\.Blob$`, 1).IgnoreKinds(`\.Cache$`, reflect.Ptr, reflect.Func).Compare(a, b)
+	suite.Equal(`Field: "service.Blob.Items[1]", A: <missing>, B: <nil>
+Field: "service.Blob.Items[Length]", A: 1, B: 2
+Field: "service.Blob.Kind", A: x, B: y
+Field: "service.Cache.Size", A: 1, B: 2
+Field: "service.Name", A: a, B: b
+`, differ.Render(""))
+	differ = NewDiffer().IgnoreBelow(`\.Blob$`, 0).IgnoreKinds(`\.Cache$`, reflect.Ptr, reflect.Func).Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	// diffs of a container itself are at its depth.
+	b.Blob.Meta["n"] = 1
+	differ = NewDiffer().IgnoreBelow(`\.(Items|Meta)$`, 0).Compare(a.Blob, b.Blob)
+	suite.Equal(`Field: "blob.Items[1]", A: <missing>, B: <nil>
+Field: "blob.Items[Length]", A: 1, B: 2
+Field: "blob.Kind", A: x, B: y
+Field: "blob.Meta[Length]", A: 1, B: 2
+Field: "blob.Meta[n]", A: <nil>, B: <not nil>
+`, differ.Render(""))
+	differ = NewDiffer().IgnoreBelow(`\.Blob$`, 0).Includes(`Blob`).Compare(a, b)
+	suite.True(differ.HasDiffs())
+
+	depth, ok := relativeDepth(`Root.Blob.Items["a.b"].Name`, regexp.MustCompile(`\.Blob$`))
+	suite.True(ok)
+	suite.Equal(3, depth)
+	_, ok = relativeDepth("Root.Blob", regexp.MustCompile(`\.Cache$`))
+	suite.False(ok)
+}
+
+func (suite *DiffTestSuite) TestMatchingSemantics() {
+	type order struct {
+		Id                int
+		IdempotencyKey    string
+		CustomerReference string
+	}
+	a := order{1, "k1", "r1"}
+	b := order{2, "k2", "r2"}
+	suite.Len(NewDiffer().Ignore("Id").Compare(a, b).Diffs(), 1)
+	differ := NewDiffer().Ignore(`.*\.Id`).WithFullMatch().Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	_, ok := differ.FindDiff("order.IdempotencyKey")
+	suite.True(ok)
+
+	differ = NewDiffer().WithCaseInsensitive().Includes(`\.customerreference$`).Compare(a, b)
+	suite.Len(differ.Diffs(), 1)
+	_, ok = differ.FindDiff("order.CustomerReference")
+	suite.True(ok)
+
+	base := NewDiffer().WithFullMatch().WithCaseInsensitive()
+	differ = NewDiffer().Ignore(`order\.id`).Merge(base).Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	_, err := base.Clone().IgnoreE(`(`)
+	suite.Error(err)
+}
+
+func (suite *DiffTestSuite) TestRuleStats() {
+	me := &Person{Name: "sjl", Age: 20, Parents: []*Person{{Name: "p1", Age: 30}, {Name: "p2", Age: 40}}}
+	he := &Person{Name: "kxc", Age: 21, Parents: []*Person{{Name: "p2", Age: 40}, {Name: "p1", Age: 30}}}
+	differ := NewDiffer().Ignore(`Name$`, `Unused`).WithSorter(&pSorter{regexp.MustCompile(`Person\.Parents`)})
+	differ.Compare(me, he).Compare(me, he)
+	stats := differ.RuleStats()
+	suite.Len(stats, 3)
+	suite.Equal(RuleStat{Kind: "ignore", Rule: `Name$`, Hits: 2}, stats[0])
+	suite.Equal(RuleStat{Kind: "ignore", Rule: `Unused`, Hits: 0}, stats[1])
+	suite.Equal(RuleStat{Kind: "sorter", Rule: "*sdiffer.pSorter", Hits: 2}, stats[2])
+	differ.Fingerprint(me)
+	suite.Equal(2, differ.RuleStats()[0].Hits)
+	suite.Equal(0, differ.Reset().Ignore(`Name$`).RuleStats()[0].Hits)
+
+	differ = NewDiffer().Includes(`Age$`).WithComparator(new(parentsComparator)).Compare(me, he)
+	stats = differ.RuleStats()
+	suite.Equal(1, stats[0].Hits)
+	suite.Equal(RuleStat{Kind: "comparator", Rule: "*sdiffer.parentsComparator", Hits: 1}, stats[1])
+
+	me, he = &Person{Name: "sjl", Parents: []*Person{{Name: "p"}}}, &Person{Name: "kxc", Parents: []*Person{{Name: "p"}}}
+	differ = NewDiffer().Includes(`Name$`).WithComparator(NewMoneyComparator(`Price$`)).WithReportEqual().Compare(me, he)
+	stats = differ.RuleStats()
+	suite.Equal(RuleStat{Kind: "include", Rule: `Name$`, Hits: 1}, stats[0])
+	suite.Equal(RuleStat{Kind: "comparator", Rule: `Price$`, Hits: 0}, stats[1])
+}
+
+func (suite *DiffTestSuite) TestValidateMutations() {
+	me := &Person{
+		Name:    "sjl",
+		Age:     20,
+		Loc:     &Location{"Ji'An", newLoc("JiangXi")},
+		StrArr:  []string{"hello"},
+		Parents: []*Person{{Name: "p1", Age: 30}},
+	}
+	var paths []string
+	for _, m := range NewDiffer().Mutations(me) {
+		paths = append(paths, m.Path)
+		suite.IsType(me, m.Value)
+	}
+	suite.Equal([]string{"Person.Name", "Person.Age", "Person.Loc.Name", "Person.Loc.Province.Name",
+		"Person.StrArr[0]", "Person.Parents[0].Name", "Person.Parents[0].Age"}, paths)
+	suite.Equal("sjl", me.Name)
+	suite.Equal("JiangXi", me.Loc.Province.Name)
+
+	suite.Empty(NewDiffer().ValidateMutations(me))
+	suite.Empty(NewDiffer().Ignore(`\.Loc\.`).ValidateMutations(me))
+
+	failures := NewDiffer().WithComparator(new(parentsComparator)).ValidateMutations(me)
+	suite.Len(failures, 2)
+	suite.Equal("mutation of Person.Parents[0].Name is reported as [Person.Parents]", failures[0].Error())
+	suite.Equal("Person.Parents[0].Age", failures[1].Path)
+	suite.Empty(failures[1].Reported)
+
+	differ := NewDiffer().WithMaxDiffs(1)
+	failures = differ.ValidateMutations(me, OptIncludes(`Person\.Name$`), OptTrimSpace(`Person\.Name$`))
+	suite.Empty(failures)
+	suite.Empty(differ.Diffs())
+}
+
+func (suite *DiffTestSuite) TestResultMerge() {
+	me := &Person{Name: "sjl", Age: 20, Loc: newLoc("JiAn")}
+	he := &Person{Name: "kxc", Age: 21, Loc: newLoc("JiangXi")}
+	names := NewDiffer().WithLabel("shard").Includes(`Name`).Compare(me, he).Result()
+	ages := NewDiffer().Includes(`Age|Person\.Name`).Compare(me, he).Result()
+	merged := names.Merge(ages)
+	suite.Equal("shard", merged.Label)
+	suite.Len(merged.Diffs, 3)
+	suite.Empty(merged.Conflicts)
+
+	other := &Person{Name: "sjl", Age: 22}
+	conflicting := NewDiffer().Includes(`Person\.Age`).Compare(me, other).Result()
+	equalName := NewDiffer().Includes(`Person\.Name`).WithReportEqual().Compare(me, other).Result()
+	merged.Merge(conflicting).Merge(equalName)
+	suite.Len(merged.Diffs, 3)
+	suite.Empty(merged.Equal)
+	suite.Len(merged.Conflicts, 2)
+	suite.Equal("Person.Age", merged.Conflicts[0].Path)
+	suite.Equal(21, merged.Conflicts[0].Kept.B())
+	suite.Equal(22, merged.Conflicts[0].Dropped.B())
+	suite.Equal("Person.Name", merged.Conflicts[1].Path)
+	suite.Equal(NoDiff, merged.Conflicts[1].Dropped.Type())
+}
+
+func (suite *DiffTestSuite) TestCompareLazy() {
+	load := func(p *Person, err error) Loader {
+		return func() (interface{}, error) {
+			time.Sleep(50 * time.Millisecond)
+			return p, err
+		}
+	}
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "sjl", Age: 21}
+	var hooked *Timings
+	differ, err := NewDiffer().WithPostHook(func(res *Result) { hooked = res.Timings }).
+		CompareLazy(load(me, nil), load(he, nil))
+	suite.NoError(err)
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 21\n", differ.String())
+	timings, ok := differ.Timings()
+	suite.True(ok)
+	suite.GreaterOrEqual(int64(timings.FetchA), int64(50*time.Millisecond))
+	suite.GreaterOrEqual(int64(timings.FetchB), int64(50*time.Millisecond))
+	suite.NotNil(differ.Result().Timings)
+	suite.Equal(&timings, hooked)
+	_, ok = differ.Compare(me, he).Timings()
+	suite.False(ok)
+	suite.Nil(differ.Result().Timings)
+
+	boom := errors.New("boom")
+	differ, err = NewDiffer().CompareLazy(load(me, nil), load(nil, boom), OptIgnore(`Age`))
+	var fe *FetchError
+	suite.True(errors.As(err, &fe))
+	suite.Nil(fe.ErrA)
+	suite.True(errors.Is(err, boom))
+	suite.Equal("fetch b: boom", err.Error())
+	suite.False(differ.HasDiffs())
+	_, ok = NewDiffer().Compare(me, he).Timings()
+	suite.False(ok)
+}
+
+func (suite *DiffTestSuite) TestPostHook() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "sjl", Age: 21}
+	var calls []string
+	differ := NewDiffer().WithLabel("hooked").
+		WithPostHook(func(res *Result) { calls = append(calls, fmt.Sprintf("%s:%d", res.Label, len(res.Diffs))) }).
+		WithPostHook(func(res *Result) { calls = append(calls, "second") })
+	differ.Compare(me, he)
+	differ.CompareAt(me, he, "Person.Name")
+	suite.Equal([]string{"hooked:1", "second", "hooked:1", "second"}, calls)
+	NewDiffer().Compare(me, me, OptPostHook(func(res *Result) { calls = append(calls, "opt") }))
+	suite.Equal("opt", calls[len(calls)-1])
+}
+
+type event struct {
+	At   time.Time
+	Name string
+}
+
+func (suite *DiffTestSuite) TestTimeWindowMatch() {
+	t0 := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	a := []*event{{t0, "start"}, {t0.Add(10 * time.Second), "tick"}, {t0.Add(20 * time.Second), "stop"}}
+	b := []*event{{t0.Add(5 * time.Second), "extra"}, {t0.Add(time.Second), "start"}, {t0.Add(21 * time.Second), "halt"}}
+	timeOf := func(e interface{}) time.Time { return e.(*event).At }
+	timeEqual := func(a, b time.Time) bool { return a.Equal(b) }
+	differ := NewDiffer().Ignore(`\.At$`).WithTypeComparer(timeEqual).WithTimeWindowMatch(`^\$$`, 2*time.Second, timeOf).Compare(a, b)
+	suite.Equal("Field: \"$[2].Name\", A: stop, B: halt\n"+
+		"Field: \"$[UnmatchedA][1]\", A: &{At:2021-01-01 10:00:10 +0000 UTC Name:tick}, B: <missing>\n"+
+		"Field: \"$[UnmatchedB][0]\", A: <missing>, B: &{At:2021-01-01 10:00:05 +0000 UTC Name:extra}\n", differ.String())
+	suite.Len(NewDiffer().WithTypeComparer(timeEqual).Compare(a, b, OptIgnore(`\.At$`)).Diffs(), 3)
+}
+
+func (suite *DiffTestSuite) TestKeyMatcher() {
+	a := map[string]int{"1.0": 1, "2": 2, "3": 3}
+	b := map[string]int{"1": 1, "2.0": 3, "4": 4}
+	differ := NewDiffer().WithKeyMatcher(`^\$$`, NumericKeyTolerance(0)).Compare(a, b)
+	suite.Equal("Field: \"$[2]\", A: 2, B: 3\n"+
+		"Field: \"$[3]\", A: <not nil>, B: <nil>\n"+
+		"Field: \"$[4]\", A: <nil>, B: <not nil>\n", differ.String())
+
+	ta := time.Date(2021, 1, 1, 10, 0, 1, 0, time.UTC)
+	tb := time.Date(2021, 1, 1, 10, 0, 59, 0, time.UTC)
+	suite.False(NewDiffer().WithKeyMatcher(`.`, TimeKeyWindow(time.Minute)).
+		Compare(map[time.Time]int{ta: 1}, map[time.Time]int{tb: 1}).HasDiffs())
+	suite.True(NewDiffer().Compare(map[time.Time]int{ta: 1}, map[time.Time]int{tb: 1}).HasDiffs())
+	suite.False(NewDiffer().Compare(map[string]int{"2021-01-01T10:00:01Z": 1}, map[string]int{"2021-01-01T10:00:30Z": 1},
+		OptKeyMatcher(`.`, TimeKeyWindow(time.Minute))).HasDiffs())
+	suite.False(NewDiffer().Compare(map[string]int{"user:1": 1}, map[string]int{"user:1:v2": 1},
+		OptKeyMatcher(`.`, PrefixKeyMatcher())).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestDeltaThreshold() {
+	a := map[string]float64{"cpu": 100, "mem": 1000, "disk": 0, "io": 10}
+	b := map[string]float64{"cpu": 104, "mem": 1200, "disk": 1, "io": 10.5}
+	differ := NewDiffer().WithDeltaThreshold(`cpu|disk`, 0, 5).WithDeltaThreshold(`mem|io`, 100, 0).Compare(a, b)
+	suite.Equal("Field: \"$[disk]\", A: 0, B: 1\n"+
+		"Field: \"$[mem]\", A: 1000, B: 1200\n", differ.String())
+	df, _ := differ.FindDiff("$[mem]")
+	delta, percent, ok := df.Delta()
+	suite.True(ok)
+	suite.Equal(200.0, delta)
+	suite.Equal(20.0, percent)
+	js, err := json.Marshal(df)
+	suite.NoError(err)
+	suite.Equal(`{"path":"$[mem]","type":"ElemDiff","a":1000,"b":1200,"delta":200,"percent":20}`, string(js))
+	df, _ = differ.FindDiff("$[disk]")
+	_, percent, _ = df.Delta()
+	suite.True(math.IsNaN(percent))
+	_, _, ok = newDiff("x", "a", "b").Delta()
+	suite.False(ok)
+	suite.Len(NewDiffer().Compare(a, b, OptDeltaThreshold(`.`, 1, 1)).Diffs(), 4)
+}
+
+func (suite *DiffTestSuite) TestChangeClassification() {
+	me := &Person{Name: "sjl", Age: 20, StrArr: []string{"a b", "x", "y"}, Loc: newLoc("JiAn")}
+	he := &Person{Name: "SJL", Age: 18, StrArr: []string{"ab", "z", "y"}, Loc: newLoc("JiangXi")}
+	differ := NewDiffer().WithChangeClassification().Compare(me, he)
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 18 (decreased by 2)\n"+
+		"Field: \"Person.Loc.Name\", A: JiAn, B: JiangXi\n"+
+		"Field: \"Person.Name\", A: sjl, B: SJL (case-only)\n"+
+		"Field: \"Person.StrArr[0]\", A: a b, B: ab (whitespace-only)\n"+
+		"Field: \"Person.StrArr[1]\", A: x, B: z\n", differ.String())
+	df, _ := differ.FindDiff("Person.Age")
+	change, delta := df.Change()
+	suite.Equal(Decreased, change)
+	suite.Equal(-2.0, delta)
+	suite.Len(differ.Query().ChangeIs(CaseOnly, WhitespaceOnly).Slice(), 2)
+	change, delta = newDiff("x", 1.5, 2.0).Change()
+	suite.Equal("increased", change.String())
+	suite.Equal(0.5, delta)
+	suite.NotContains(NewDiffer().Compare(me, he).String(), "decreased")
+}
+
+func (suite *DiffTestSuite) TestComparisonID() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "sjl", Age: 21}
+	differ := NewDiffer().WithComparisonID("req-1").Compare(me, he)
+	suite.Equal("[req-1] Field: \"Person.Age\", A: 20, B: 21\n", differ.String())
+	suite.Equal("req-1", differ.ComparisonID())
+	suite.Equal("req-1", differ.Result().ComparisonID)
+	js, err := json.Marshal(differ.Diffs()[0])
+	suite.NoError(err)
+	suite.Contains(string(js), `"comparisonId":"req-1"`)
+
+	differ = NewDiffer().WithComparisonID("")
+	id1 := differ.Compare(me, he).ComparisonID()
+	suite.Len(id1, 16)
+	suite.Equal(id1, differ.Diffs()[0].ComparisonID())
+	suite.NotEqual(id1, differ.Reset().WithComparisonID("").Compare(me, he).ComparisonID())
+	suite.Empty(NewDiffer().Compare(me, he).ComparisonID())
+	suite.Equal("x", NewDiffer().Compare(me, he, OptComparisonID("x")).ComparisonID())
+}
+
+func (suite *DiffTestSuite) TestSourceInfo() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "sjl", Age: 21}
+	infoA := map[string]interface{}{"env": "prod", "id": 1}
+	infoB := map[string]interface{}{"env": "shadow", "id": 1}
+	differ := NewDiffer().WithLabel("case").WithSourceInfo(infoA, infoB).Compare(me, he)
+	suite.Equal("[case]\n[A] env=prod id=1\n[B] env=shadow id=1\n"+
+		"Field: \"Person.Age\", A: 20, B: 21\n", differ.String())
+	df, _ := differ.FindDiff("Person.Age")
+	suite.Equal(infoA, df.SourceA())
+	suite.Equal(infoB, df.SourceB())
+	suite.Equal(infoB, differ.Result().SourceB)
+	js, err := json.Marshal(df)
+	suite.NoError(err)
+	suite.Equal(`{"path":"Person.Age","type":"ElemDiff","a":20,"b":21,"sourceA":{"env":"prod","id":1},"sourceB":{"env":"shadow","id":1},"delta":1,"percent":5}`, string(js))
+
+	differ = NewDiffer().Compare(me, he, OptSourceInfo(nil, map[string]interface{}{"id": 2}))
+	suite.Equal("[B] id=2\nField: \"Person.Age\", A: 20, B: 21\n", differ.String())
+}
+
+func (suite *DiffTestSuite) TestMaxReportBytes() {
+	long := strings.Repeat("x", 100)
+	me := &Person{Name: "sjl" + long, Age: 20, Loc: newLoc("JiAn")}
+	he := &Person{Name: "kxc" + long, Age: 21, Loc: newLoc("JiangXi")}
+	differ := NewDiffer().Compare(me, he)
+	suite.Equal(differ.String(), differ.Compare(me, he, OptMaxReportBytes(1000)).String())
+
+	differ = NewDiffer().WithMaxReportBytes(300).Compare(me, he)
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 21\n"+
+		"Field: \"Person.Loc.Name\", A: JiAn, B: JiangXi\n"+
+		"Field: \"Person.Name\", A: sjl"+long[:61]+"..., B: kxc"+long[:61]+"...\n", differ.String())
+
+	differ = NewDiffer().WithMaxReportBytes(120).WithMustEqual(`Loc`).Compare(me, he)
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 21\n"+
+		"Field: \"Person.Loc.Name\", A: JiAn, B: JiangXi\n"+
+		"... and 1 more diffs\n", differ.String())
+	differ = NewDiffer().WithMaxReportBytes(60).WithSeverity(func(df *Diff) Severity {
+		return iF(df.Name() == "Person.Age", SeverityHigh, SeverityLow).(Severity)
+	}).Compare(me, he)
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 21\n... and 2 more diffs\n", differ.String())
+	suite.Equal(strings.Repeat("界", 64)+"...", truncateValue(strings.Repeat("界", 100)))
+	suite.Equal("... and 4,812 more diffs\n", moreDiffs(4812))
+	suite.Equal("... and 1,234,567 more diffs\n", moreDiffs(1234567))
+}
+
+func (suite *DiffTestSuite) TestMemoization() {
+	pa, pb := &Person{Name: "p", Loc: newLoc("JiAn")}, &Person{Name: "p", Loc: newLoc("JiAn")}
+	a := &Person{Name: "sjl", Parents: []*Person{pa, pa, pa}}
+	b := &Person{Name: "kxc", Parents: []*Person{pb, pb, pb}}
+	plain := NewDiffer().Compare(a, b)
+	differ := NewDiffer().WithMemoization().Compare(a, b)
+	suite.Equal(plain.String(), differ.String())
+	suite.Less(differ.nodeCount, plain.nodeCount)
+
+	pb.Loc.Name = "JiangXi"
+	differ = NewDiffer().Compare(a, b, OptMemoization())
+	suite.Len(differ.Diffs(), 4)
+}
+
+func (suite *DiffTestSuite) TestMapHashing() {
+	a, b := make(map[int]*Person, 100), make(map[int]*Person, 100)
+	for i := 0; i < 100; i++ {
+		a[i] = &Person{Name: "sjl", Age: i}
+		b[i] = &Person{Name: "sjl", Age: i}
+	}
+	b[42].Age = 0
+	compared := 0
+	hasher := func(v interface{}) uint64 {
+		compared++
+		return uint64(v.(*Person).Age)
+	}
+	differ := NewDiffer().WithMapHashing(10, hasher).Compare(a, b)
+	suite.Equal("Field: \"$[42].Age\", A: 42, B: 0\n", differ.String())
+	suite.Equal(200, compared)
+	differ = NewDiffer().Compare(map[string]int{"a": 1, "b": 2}, map[string]int{"a": 1, "b": 3}, OptMapHashing(2, nil))
+	suite.Equal("Field: \"$[b]\", A: 2, B: 3\n", differ.String())
+	suite.False(NewDiffer().WithMapHashing(1, nil).Compare(a, a).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestQuotedKeys() {
+	a := map[string]int{"a.b": 1, "x]": 1, "l\n2": 1, "plain": 1}
+	b := map[string]int{"a.b": 2, "x]": 2, "l\n2": 2, "plain": 2}
+	differ := NewDiffer().Compare(a, b)
+	suite.Equal("Field: \"$[\"a.b\"]\", A: 1, B: 2\n"+
+		"Field: \"$[\"l\\n2\"]\", A: 1, B: 2\n"+
+		"Field: \"$[\"x]\"]\", A: 1, B: 2\n"+
+		"Field: \"$[plain]\", A: 1, B: 2\n", differ.String())
+	suite.Equal(`["a.b"]`, KeySegment("a.b"))
+	suite.Equal(`[plain]`, KeySegment("plain"))
+
+	differ = NewDiffer().Ignore(regexp.QuoteMeta(KeySegment("x]")), `^\$\[plain\]$`).Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	_, ok := differ.FindDiff(`$["a.b"]`)
+	suite.True(ok)
+	v, err := Get(a, `$["x]"]`)
+	suite.NoError(err)
+	suite.Equal(1, v)
+	suite.Equal("Person.Tags.Name", newDiff(`Person.Tags["a.b"].Name`, 1, 2).Tag())
+}
+
+func (suite *DiffTestSuite) TestJSONPath() {
+	a := map[string][]int{"a.b": {1}, "it's": {1}, "Length": {1, 2}}
+	b := map[string][]int{"a.b": {2}, "it's": {1, 2}, "Length": {1, 3}}
+	differ := NewDiffer().WithJSONPath().Compare(a, b)
+	suite.Equal("Field: \"$['Length'][1]\", A: 2, B: 3\n"+
+		"Field: \"$['a.b'][0]\", A: 1, B: 2\n"+
+		"Field: \"$['it\\'s'].length()\", A: 1, B: 2\n"+
+		"Field: \"$['it\\'s'][1]\", A: <missing>, B: 2\n", differ.String())
+	v, err := Get(a, "$['it\\'s'][0]")
+	suite.NoError(err)
+	suite.Equal(1, v)
+
+	differ = NewDiffer().Ignore(`\['a\.b'\]`).Compare(a, b, OptJSONPath())
+	suite.Len(differ.Diffs(), 3)
+
+	differ, err = NewDiffer().WithJSONPath().CompareJSON([]byte(`{"x.y": [1]}`), []byte(`{"x.y": [2]}`))
+	suite.NoError(err)
+	df, ok := differ.FindDiff("$['x.y'][0]")
+	suite.True(ok)
+	_, ok = df.PosA()
+	suite.True(ok)
+}
+
+type orderItem struct {
+	Price float64
+}
+
+type order struct {
+	Total float64
+	Paid  float64
+	Items []orderItem
+}
+
+func (suite *DiffTestSuite) TestCrossField() {
+	a := &order{Total: 3, Paid: 3, Items: []orderItem{{1}, {2}}}
+	b := &order{Total: 3, Paid: 4, Items: []orderItem{{1}, {2.5}}}
+	sumRule := func(a, b interface{}) (interface{}, interface{}, bool) {
+		total, _ := Get(a, "order.Total")
+		sum, _ := Sum(b, "order.Items[*].Price")
+		return total, sum, total == sum
+	}
+	differ := NewDiffer().Ignore(`\.Items\[\d+\]`, `^order\.Paid$`).
+		WithCrossField("order.Total", "order.Paid").
+		WithCrossFieldRule("order.Total = sum(order.Items[*].Price)", sumRule).Compare(a, b)
+	suite.Equal("Field: \"order.Total = sum(order.Items[*].Price)\", A: 3, B: 3.5\n"+
+		"Field: \"order.Total ~ order.Paid\", A: 3, B: 4\n", differ.String())
+	suite.Equal(CrossFieldDiff, differ.Diffs()[0].Type())
+	suite.False(NewDiffer().Compare(a, a, OptCrossField("order.Total", "order.Paid")).HasDiffs())
+	suite.True(NewDiffer().Compare(a, a, OptCrossField("order.Total", "order.Nope")).HasDiffs())
+
+	prices, err := GetAll(b, "order.Items[*].Price")
+	suite.NoError(err)
+	suite.Equal([]interface{}{1.0, 2.5}, prices)
+	_, err = Sum(b, "order.Items")
+	suite.Error(err)
+}
+
+func (suite *DiffTestSuite) TestNamedSections() {
+	me := &Person{Name: "sjl", Age: 20, Loc: newLoc("JiAn"), StrArr: []string{"a"}}
+	he := &Person{Name: "kxc", Age: 21, Loc: newLoc("JiangXi"), StrArr: []string{"b"}}
+	differ := NewDiffer().WithSection("identity", `\.Name$`, `\.Age$`).Compare(me, he)
+	suite.Equal("== StrArr ==\n"+
+		"Field: \"Person.StrArr[0]\", A: a, B: b\n"+
+		"== identity ==\n"+
+		"Field: \"Person.Age\", A: 20, B: 21\n"+
+		"Field: \"Person.Loc.Name\", A: JiAn, B: JiangXi\n"+
+		"Field: \"Person.Name\", A: sjl, B: kxc\n", differ.String())
+	suite.True(differ.HasDiffsIn("identity"))
+	suite.Len(differ.SectionDiffs("identity"), 3)
+	suite.False(differ.HasDiffsIn("metadata"))
+	suite.True(NewDiffer().Compare(me, he, OptSection("strs", `StrArr`)).HasDiffsIn("strs"))
+}
+
+func (suite *DiffTestSuite) TestMustEqual() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "sjl", Age: 21}
+	differ := NewDiffer().WithMustEqual(`Person\.Name`)
+	suite.NoError(differ.CompareE(me, me))
+
+	err := differ.CompareE(me, he)
+	var nee *NotEqualError
+	suite.True(errors.As(err, &nee))
+	suite.False(errors.Is(err, ErrMustEqual))
+	suite.Len(nee.Result.Diffs, 1)
+	suite.False(differ.Compare(me, he).MustEqualViolated())
+
+	he.Name = "kxc"
+	suite.True(errors.Is(differ.CompareE(me, he), ErrMustEqual))
+	suite.True(NewDiffer().Compare(me, he, OptMustEqual(`Name`)).MustEqualViolated())
+	suite.False(differ.Reset().Compare(me, he).MustEqualViolated())
+}
+
+func (suite *DiffTestSuite) TestExpectedDiff() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "kxc", Age: 21}
+	differ := NewDiffer().WithExpectedDiff(`Person\.Age`, 20, 21).Compare(me, he)
+	suite.True(differ.HasDiffs())
+	suite.Len(differ.Diffs(), 1)
+	suite.Len(differ.ExpectedDiffs(), 1)
+	suite.Equal("Person.Age", differ.ExpectedDiffs()[0].Path())
+	suite.Len(differ.Result().Expected, 1)
+
+	he.Name = "sjl"
+	suite.False(NewDiffer().Compare(me, he, OptExpectedDiff(`Age`, 20, 21)).HasDiffs())
+	he.Age = 22
+	suite.True(NewDiffer().Compare(me, he, OptExpectedDiff(`Age`, 20, 21)).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestSuppression() {
+	me := &Person{Name: "sjl", Age: 20}
+	he := &Person{Name: "kxc", Age: 21}
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	differ := NewDiffer().WithSuppression(
+		Suppression{Path: `Person\.Age`, Owner: "sjl", Reason: "flaky", Expires: now.Add(-time.Hour)},
+		Suppression{Path: `Person\.Loc`, Owner: "kxc"},
+	).Ignore(`Person\.Parents`)
+	differ.Compare(me, he)
+	suite.Len(differ.Diffs(), 1)
+	suite.Len(differ.Suppressions(), 2)
+	expired := differ.ExpiredSuppressions(now)
+	suite.Len(expired, 1)
+	suite.Equal("flaky", expired[0].Reason)
+	suite.Empty(differ.ExpiredSuppressions(now.Add(-2 * time.Hour)))
+
+	suite.False(NewDiffer().Compare(me, he, OptSuppression(Suppression{Path: `Name|Age`})).HasDiffs())
+}
+
+func (suite *DiffTestSuite) TestMultiLevelPointers() {
+	type Node struct {
+		Loc  **Location
+		Any  *interface{}
+		Self interface{}
+	}
+	loc1, loc2 := newLoc("JiAn"), newLoc("JiangXi")
+	var any1, any2 interface{} = Location{Name: "JiAn"}, Location{Name: "JiangXi"}
+	a := Node{Loc: &loc1, Any: &any1}
+	b := Node{Loc: &loc2, Any: &any2}
+	differ := NewDiffer().Compare(a, b)
+	suite.Len(differ.Diffs(), 2)
+	suite.True(differ.HasDiffsMatching(`^Node\.Loc\.Name$`))
+	suite.True(differ.HasDiffsMatching(`^Node\.Any\.Name$`))
+
+	var nilLoc *Location
+	b.Loc = &nilLoc
+	df, ok := NewDiffer().Compare(a, b).FindDiff("Node.Loc")
+	suite.True(ok)
+	suite.Equal(NilDiff, df.Type())
+
+	a, b = Node{Self: loc1}, Node{Self: *loc2}
+	differ = NewDiffer().Compare(a, b)
+	df, ok = differ.FindDiff("Node.Self" + indirectionSuffix)
+	suite.True(ok)
+	suite.Equal("1", df.A())
+	suite.Equal("0", df.B())
+	suite.True(differ.HasDiffsMatching(`^Node\.Self\.Name$`))
+
+	type P *P
+	var p1, p2 P
+	p1, p2 = &p1, &p2
+	suite.NotPanics(func() { NewDiffer().Compare(p1, p2) })
+}
+
+func (suite *DiffTestSuite) TestReportEqual() {
+	me := &Person{Name: "sjl", Age: 20, Loc: newLoc("JiAn")}
+	he := &Person{Name: "sjl", Age: 21, Loc: newLoc("JiAn")}
+	differ := NewDiffer().Ignore(`Loc`).WithReportEqual().Compare(me, he)
+	suite.Len(differ.Diffs(), 1)
+	equals := differ.EqualFields()
+	suite.Len(equals, 1)
+	suite.Equal("Person.Name", equals[0].Path())
+	suite.Equal(NoDiff, equals[0].Type())
+	suite.Equal("Field: \"Person.Age\", A: 20, B: 21\n"+
+		"Field: \"Person.Name\", A: sjl, B: sjl (equal)\n", differ.String())
+	suite.Len(differ.Result().Equal, 1)
+
+	differ = NewDiffer().Compare(me, me, OptReportEqual())
+	suite.False(differ.HasDiffs())
+	suite.Len(differ.EqualFields(), 0)
+	suite.Len(NewDiffer().Compare(me, he, OptReportEqual()).EqualFields(), 2)
+	suite.Nil(NewDiffer().Compare(me, he).EqualFields())
+}
+
+func (suite *DiffTestSuite) TestSliceContext() {
+	me := &Person{StrArr: []string{"a", "b", "c", "d"}, Parents: []*Person{{Name: "x"}, {Name: "y"}}}
+	he := &Person{StrArr: []string{"a", "b", "x", "c"}, Parents: []*Person{{Name: "x"}, {Name: "z"}}}
+	differ := NewDiffer().WithSliceContext(1).Compare(me, he)
+	df, ok := differ.FindDiff("Person.StrArr[2]")
+	suite.True(ok)
+	ctx, ok := df.Context()
+	suite.True(ok)
+	suite.Equal("Person.StrArr", ctx.Path)
+	suite.Equal(2, ctx.Index)
+	suite.Equal(1, ctx.Start)
+	suite.Equal([]interface{}{"b", "c", "d"}, ctx.A)
+	suite.Equal([]interface{}{"b", "x", "c"}, ctx.B)
+
+	df, _ = differ.FindDiff("Person.Parents[1].Name")
+	ctx, ok = df.Context()
+	suite.True(ok)
+	suite.Equal("Person.Parents", ctx.Path)
+	suite.Len(ctx.A, 2)
+	bs, err := json.Marshal(df)
+	suite.NoError(err)
+	suite.Contains(string(bs), `"context":{"path":"Person.Parents","index":1,"start":0`)
+
+	df, _ = NewDiffer().Compare(me, he).FindDiff("Person.StrArr[2]")
+	_, ok = df.Context()
+	suite.False(ok)
+	df, _ = NewDiffer().Compare(me, he, OptSliceContext(5)).FindDiff("Person.StrArr[3]")
+	ctx, _ = df.Context()
+	suite.Equal(0, ctx.Start)
+	suite.Len(ctx.A, 4)
+
+	df, _ = NewDiffer().WithSliceContext(1).Compare([][]int{{1, 2}, {3, 4}}, [][]int{{1, 2}, {3, 5}}).FindDiff("$[1][1]")
+	ctx, _ = df.Context()
+	suite.Equal("$[1]", ctx.Path)
+	suite.Equal([]interface{}{3, 4}, ctx.A)
+}
+
+func (suite *DiffTestSuite) TestShiftDetection() {
+	me := &Person{StrArr: []string{"a", "b", "c", "d"}}
+	he := &Person{StrArr: []string{"x", "y", "a", "b", "c", "d"}}
+	suite.Len(NewDiffer().Compare(me, he).Diffs(), 7)
+
+	differ := NewDiffer().WithShiftDetection(`StrArr`).Compare(me, he)
+	suite.Len(differ.Diffs(), 4)
+	df, ok := differ.FindDiff("Person.StrArr[Shift]")
+	suite.True(ok)
+	suite.Equal(ShiftDiff, df.Type())
+	suite.Equal(0, df.A())
+	suite.Equal(2, df.B())
+	df, _ = differ.FindDiff("Person.StrArr[1]")
+	suite.Equal(MissingDiff, df.Type())
+	suite.Equal("y", df.B())
+	suite.True(differ.HasDiffsMatching(`StrArr\[Length\]`))
+
+	df, _ = NewDiffer().Compare(he, me, OptShiftDetection(`StrArr`)).FindDiff("Person.StrArr[Shift]")
+	suite.Equal(2, df.A())
+
+	he.StrArr[3] = "z"
+	suite.False(NewDiffer().WithShiftDetection(`StrArr`).Compare(me, he).HasDiffsMatching(`Shift`))
+}
+
+func (suite *DiffTestSuite) TestCompareAt() {
+	me := &Person{Name: "sjl", Loc: newLoc("JiAn"), Parents: []*Person{{Name: "a", Age: 50}}}
+	he := &Person{Name: "kxc", Loc: newLoc("JiAn"), Parents: []*Person{{Name: "b", Age: 50}}}
+	differ := NewDiffer().CompareAt(me, he, "Person.Parents[0]")
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Person.Parents[0].Name")
+	suite.True(ok)
+	suite.False(NewDiffer().CompareAt(me, he, "Person.Loc").HasDiffs())
+
+	a := Building{BuildingMap: map[string]string{"a.b": "1"}}
+	b := Building{BuildingMap: map[string]string{"a.b": "2"}}
+	_, ok = NewDiffer().CompareAt(a, b, "Building.BuildingMap[a.b]").FindDiff("Building.BuildingMap[a.b]")
+	suite.True(ok)
+
+	suite.Panics(func() { NewDiffer().CompareAt(me, he, "Person.Parents[1]") })
+	suite.Panics(func() { NewDiffer().CompareAt(me, he, "Location.Name") })
+	suite.Panics(func() { NewDiffer().CompareAt(me, he, "Person.Unknown") })
+}
+
+func (suite *DiffTestSuite) TestGet() {
+	me := &Person{Name: "sjl", StrArr: []string{"a", "b"}, Parents: []*Person{{Loc: newLoc("JiAn")}}}
+	he := &Person{Name: "kxc", StrArr: []string{"a"}, Parents: []*Person{{Loc: newLoc("JiangXi")}}}
+	for _, df := range NewDiffer().Compare(me, he).Diffs() {
+		va, err := Get(me, df.Path())
+		suite.NoError(err, df.Path())
+		suite.Equal(df.A(), va)
+	}
+	v, err := Get(me, "Person.StrArr[Length]")
+	suite.NoError(err)
+	suite.Equal(2, v)
+	v, err = Get(Building{BuildingMap: map[string]string{"k": "v"}}, "Building.BuildingMap[k]")
+	suite.NoError(err)
+	suite.Equal("v", v)
+
+	_, err = Get(me, "Person.Loc.Name")
+	suite.Error(err)
+	_, err = Get(me, "Person.Name[Length]x")
+	suite.Error(err)
+}
+
+func (suite *DiffTestSuite) TestQuery() {
+	me := &Person{Name: "sjl", Loc: newLoc("JiAn"), StrArr: []string{"a", "b", "c"}}
+	he := &Person{Name: "kxc", StrArr: []string{"x", "y"}}
+	differ := NewDiffer().Compare(me, he)
+	suite.Len(differ.Query().PathMatches(`StrArr`).Slice(), 4)
+	suite.Len(differ.Query().PathMatches(`StrArr`).Limit(2).Slice(), 2)
+	suite.Equal(4, differ.Query().PathMatches(`StrArr`).Limit(2).Count())
+	suite.Len(differ.Query().PathPrefix("Person.StrArr").TypeIs(LengthDiff, MissingDiff).Slice(), 2)
+	df, ok := differ.Query().TypeIs(NilDiff).First()
+	suite.True(ok)
+	suite.Equal("Person.Loc", df.Path())
+	suite.Len(differ.Result().Query().Where(func(df *Diff) bool { return df.A() == "sjl" }).Slice(), 1)
+	suite.Nil(differ.Query().PathPrefix("Person.Age").Slice())
+}
+
+func (suite *DiffTestSuite) TestIterAndChunks() {
+	me := &Person{Name: "sjl", StrArr: []string{"a", "b", "c"}}
+	he := &Person{Name: "kxc", StrArr: []string{"x", "y", "z"}}
+	differ := NewDiffer().Compare(me, he)
+	var paths []string
+	it := differ.Iter()
+	suite.Nil(it.Value())
+	for it.Next() {
+		paths = append(paths, it.Value().Path())
+	}
+	suite.Equal([]string{"Person.Name", "Person.StrArr[0]", "Person.StrArr[1]", "Person.StrArr[2]"}, paths)
+	suite.False(it.Next())
+	suite.Nil(it.Value())
+
+	var sizes []int
+	suite.NoError(differ.Chunks(3, func(chunk []*Diff) error {
+		sizes = append(sizes, len(chunk))
+		return nil
+	}))
+	suite.Equal([]int{3, 1}, sizes)
+	errStop := errors.New("stop")
+	suite.Equal(errStop, differ.Chunks(1, func(chunk []*Diff) error { return errStop }))
+}
+
+func (suite *DiffTestSuite) TestPathLabels() {
+	me := &Person{Name: "sjl", Parents: []*Person{{Age: 50}}}
+	he := &Person{Name: "kxc", Parents: []*Person{{Age: 51}}}
+	labels := map[string]string{"Person.Name": "Customer Name", "Person.Parents.Age": "Parent Age"}
+	differ := NewDiffer().WithPathLabels(labels).Compare(me, he)
+	suite.Equal("Field: \"Customer Name\", A: sjl, B: kxc\n"+
+		"Field: \"Parent Age[0]\", A: 50, B: 51\n", differ.String())
+	_, ok := differ.FindDiff("Person.Name")
+	suite.True(ok)
+	suite.Contains(NewDiffer().Compare(me, he, OptPathLabels(labels)).String(), "Customer Name")
+}
+
+func (suite *DiffTestSuite) TestFindDiffs() {
+	type Item struct {
+		SKU string `json:"sku"`
+	}
+	type Order struct {
+		Items []Item `json:"items"`
+		Notes string `json:"notes,omitempty"`
+	}
+	a := Order{Items: []Item{{"a"}, {"b"}}, Notes: "x"}
+	b := Order{Items: []Item{{"c"}}, Notes: "y"}
+	differ := NewDiffer().WithPathLabels(map[string]string{"Order.Notes": "Order Notes"}).
+		WithComparator(&nameComparator{}).Compare(a, b)
+	suite.Len(differ.FindDiffs("Order.Items"), 2)
+	suite.Len(differ.FindDiffs("Order.items[Length]"), 2)
+	suite.Len(differ.FindDiffs("Order.items[0].sku"), 1)
+	suite.Len(differ.FindDiffs("Order Notes"), 1)
+	suite.Len(differ.FindDiffs("Order.notes"), 1)
+	suite.Empty(differ.FindDiffs("Order.Unknown"))
+}
+
+type nameComparator struct{}
+
+func (*nameComparator) Match(fieldPath string) bool {
+	return strings.HasSuffix(fieldPath, ".Notes")
+}
+
+func (*nameComparator) Equals(a, b interface{}) (DiffType, interface{}, interface{}) {
+	if a == b {
+		return NoDiff, nil, nil
+	}
+	return ElemDiff, a, b
+}
+
+func (suite *DiffTestSuite) TestLengthTolerance() {
+	me := &Person{StrArr: []string{"hello", "world", "hi"}}
+	he := &Person{StrArr: []string{"hello"}}
+	suite.False(NewDiffer().WithLengthTolerance(`StrArr`, 2).Compare(me, he).HasDiffs())
+	suite.True(NewDiffer().WithLengthTolerance(`StrArr`, 1).Compare(me, he).HasDiffs())
+
+	he.StrArr = []string{"hi"}
+	differ := NewDiffer().Compare(me, he, OptLengthTolerance(`StrArr`, 2))
+	suite.Len(differ.Diffs(), 1)
+	_, ok := differ.FindDiff("Person.StrArr[0]")
+	suite.True(ok)
+
+	a := Building{BuildingMap: map[string]string{"a": "1", "b": "2"}}
+	b := Building{BuildingMap: map[string]string{"a": "1"}}
+	differ = NewDiffer().WithLengthTolerance(`BuildingMap`, 1).Compare(a, b)
+	suite.Len(differ.Diffs(), 1)
+	df, _ := differ.FindDiff("Building.BuildingMap[b]")
+	suite.Equal(NilDiff, df.Type())
+
+	b.BuildingMap = map[string]string{"c": "1", "d": "2"}
+	differ = NewDiffer().WithLengthTolerance(`BuildingMap`, 1).Compare(a, b)
+	suite.Len(differ.Diffs(), 4)
+	for _, path := range []string{"a", "b", "c", "d"} {
+		_, ok = differ.FindDiff("Building.BuildingMap[" + path + "]")
+		suite.True(ok, path)
+	}
+}
+
+func (suite *DiffTestSuite) TestNilExpansion() {
+	me := &Person{Loc: &Location{Name: "JiAn"}}
+	he := &Person{}
+	suite.Len(NewDiffer().Compare(me, he).Diffs(), 1)
+
+	differ := NewDiffer().WithNilExpansion().Compare(me, he)
+	suite.Len(differ.Diffs(), 2)
+	df, ok := differ.FindDiff("Person.Loc.Name")
+	suite.True(ok)
+	suite.Equal("JiAn", df.A())
+	suite.Equal("", df.B())
+
+	type counter struct {
+		N *int
+	}
+	n := 5
+	differ = NewDiffer().WithNilExpansion().Compare(counter{}, counter{&n})
+	suite.Len(differ.Diffs(), 1)
+	df, _ = differ.FindDiff("counter.N")
+	suite.Equal(NilDiff, df.Type())
+}
+
 func (suite *DiffTestSuite) TestChore() {
 	// ...
 }
@@ -0,0 +1,149 @@
+package sdiffer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const filesRoot = "Files"
+
+// Decoder decodes file content for structural comparison, see DirDiffer.WithDecoder.
+type Decoder func(data []byte) (interface{}, error)
+
+// DirDiffer compares two directory trees: presence, size, mode and sha256 hash of regular files.
+// Diffs are recorded under paths like "Files[README].Size", names are quoted like map keys,
+// such as `Files["conf/app.json"].Size`, see KeySegment.
+//
+// For example:
+// differ, err := NewDirDiffer().WithStructural(NewDiffer().Ignore(`version`)).Compare("a", "b")
+type DirDiffer struct {
+	differ   *Differ
+	decoders map[string]Decoder
+}
+
+func NewDirDiffer() *DirDiffer {
+	return &DirDiffer{
+		decoders: make(map[string]Decoder),
+	}
+}
+
+// WithStructural makes DirDiffer compare contents of files which can be decoded structurally with d,
+// instead of comparing their sizes and hashes. JSON files are decoded by default, and more decoders
+// can be set by WithDecoder, such as yaml.Unmarshal. Contents of different types, such as [1] and {"a":1},
+// are recorded as an ElemDiff at "Files[name].Content", and null on one side as a NilDiff there.
+func (dd *DirDiffer) WithStructural(d *Differ) *DirDiffer {
+	dd.differ = d
+	if _, ok := dd.decoders[".json"]; !ok {
+		dd.decoders[".json"] = JSONCodec.Decode
+	}
+	return dd
+}
+
+// WithDecoder set the Decoder of files with extension ext, such as ".yaml".
+func (dd *DirDiffer) WithDecoder(ext string, decode Decoder) *DirDiffer {
+	dd.decoders[strings.ToLower(ext)] = decode
+	return dd
+}
+
+// Compare walks dirA and dirB and compares the files in them.
+func (dd *DirDiffer) Compare(dirA, dirB string) (*Differ, error) {
+	filesA, err := listFiles(dirA)
+	if err != nil {
+		return nil, err
+	}
+	filesB, err := listFiles(dirB)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(filesA)+len(filesB))
+	for name := range filesA {
+		names = append(names, name)
+	}
+	for name := range filesB {
+		if _, ok := filesA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	d := NewDiffer()
+	for _, name := range names {
+		path := concat(filesRoot, KeySegment(name))
+		fa, okA := filesA[name]
+		fb, okB := filesB[name]
+		if !okA || !okB {
+			d.setTypedDiff(NilDiff, path, iF(okA, notNull, null), iF(okB, notNull, null))
+			continue
+		}
+		if fa.Mode() != fb.Mode() {
+			d.setDiff(path+".Mode", fa.Mode(), fb.Mode())
+		}
+		if !fa.Mode().IsRegular() || !fb.Mode().IsRegular() {
+			continue
+		}
+		if err := dd.compareFile(d, path, filepath.Join(dirA, name), filepath.Join(dirB, name), fa, fb); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (dd *DirDiffer) compareFile(d *Differ, path, fileA, fileB string, fa, fb os.FileInfo) error {
+	decode, structural := dd.decoders[strings.ToLower(filepath.Ext(fileA))]
+	structural = structural && dd.differ != nil
+	if !structural && fa.Size() != fb.Size() {
+		d.setDiff(path+".Size", fa.Size(), fb.Size())
+		return nil
+	}
+	dataA, err := ioutil.ReadFile(fileA)
+	if err != nil {
+		return err
+	}
+	dataB, err := ioutil.ReadFile(fileB)
+	if err != nil {
+		return err
+	}
+	if structural {
+		va, errA := decode(dataA)
+		vb, errB := decode(dataB)
+		if errA == nil && errB == nil {
+			cd := dd.differ.clone()
+			d.adopt(cd.compareDecoded(va, vb), initTypeName, path+".Content")
+			return nil
+		}
+		if fa.Size() != fb.Size() {
+			d.setDiff(path+".Size", fa.Size(), fb.Size())
+			return nil
+		}
+	}
+	ha, hb := sha256.Sum256(dataA), sha256.Sum256(dataB)
+	if ha != hb {
+		d.setDiff(path+".Hash", hex.EncodeToString(ha[:]), hex.EncodeToString(hb[:]))
+	}
+	return nil
+}
+
+// listFiles lists all files in dir by their slash-separated relative paths.
+func listFiles(dir string) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,151 @@
+package sdiffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pos is a position in a source document.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// CompareJSON decodes a and b as JSON documents and compares them like Compare, a top-level null
+// on only one side is recorded as a NilDiff at the root, and a value changing its type, such as
+// 1 and "1", or {} and [], is recorded as an ElemDiff with both values. Only JSON is supported.
+// The documents are decoded in a position-preserving mode, so that a diff records where its value
+// starts in each original document, see Diff.PosA and Diff.PosB. A side has no position if the value
+// does not exist there, such as a key present in only one of the documents.
+func (d *Differ) CompareJSON(a, b []byte) (*Differ, error) {
+	va, posA, err := decodeJSONWithPos(a, d.quoteKey)
+	if err != nil {
+		return d, fmt.Errorf("decode document a: %w", err)
+	}
+	vb, posB, err := decodeJSONWithPos(b, d.quoteKey)
+	if err != nil {
+		return d, fmt.Errorf("decode document b: %w", err)
+	}
+	d.compareDecoded(va, vb)
+	for name, df := range d.diffs {
+		name = strings.TrimSuffix(strings.TrimSuffix(name, useComparatorSuffix), lengthSuffix)
+		if p, ok := posA[name]; ok && df.posA == nil {
+			df.posA = &p
+		}
+		if p, ok := posB[name]; ok && df.posB == nil {
+			df.posB = &p
+		}
+	}
+	return d, nil
+}
+
+// compareDecoded compares values decoded from documents like Compare, their types may differ
+// anywhere: a nil root on only one side is recorded as a NilDiff at the root, and values of different
+// types, at the root or held by interfaces, are recorded as ElemDiffs instead of panicking.
+func (d *Differ) compareDecoded(va, vb interface{}) *Differ {
+	if va == nil || vb == nil {
+		if (va == nil) != (vb == nil) {
+			d.setTypedDiff(NilDiff, initTypeName, iF(va == nil, null, notNull), iF(vb == nil, null, notNull))
+		}
+		return d
+	}
+	if reflect.TypeOf(va) != reflect.TypeOf(vb) {
+		d.setTypedDiff(ElemDiff, initTypeName, va, vb)
+		return d
+	}
+	d.typeChanges = true
+	defer func() { d.typeChanges = false }()
+	return d.Compare(va, vb)
+}
+
+// posDecoder decodes JSON into interface{} like json.Unmarshal,
+// and records the position of each value by the field path Differ uses.
+type posDecoder struct {
+	dec        *json.Decoder
+	data       []byte
+	lineStarts []int
+	pos        map[string]Pos
+	quoteKey   func(key string) string
+}
+
+func decodeJSONWithPos(data []byte, quoteKey func(key string) string) (v interface{}, pos map[string]Pos, err error) {
+	pd := &posDecoder{
+		quoteKey:   quoteKey,
+		dec:        json.NewDecoder(bytes.NewReader(data)),
+		data:       data,
+		lineStarts: []int{0},
+		pos:        make(map[string]Pos, 16),
+	}
+	for i, c := range data {
+		if c == '\n' {
+			pd.lineStarts = append(pd.lineStarts, i+1)
+		}
+	}
+	v, err = pd.decode(initTypeName)
+	return v, pd.pos, err
+}
+
+func (pd *posDecoder) decode(fieldPath string) (interface{}, error) {
+	start := pd.skip(int(pd.dec.InputOffset()))
+	tok, err := pd.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	pd.pos[fieldPath] = pd.position(start)
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		m := make(map[string]interface{})
+		for pd.dec.More() {
+			kt, err := pd.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := kt.(string)
+			if m[key], err = pd.decode(concat(fieldPath, pd.quoteKey(key))); err != nil {
+				return nil, err
+			}
+		}
+		_, err = pd.dec.Token()
+		return m, err
+	case '[':
+		arr := make([]interface{}, 0)
+		for i := 0; pd.dec.More(); i++ {
+			v, err := pd.decode(concat(fieldPath, "[", strconv.Itoa(i), "]"))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = pd.dec.Token()
+		return arr, err
+	}
+	return nil, fmt.Errorf("unexpected delim %v", delim)
+}
+
+// skip skips spaces and separators to find the start offset of the next value.
+func (pd *posDecoder) skip(offset int) int {
+	for offset < len(pd.data) && strings.IndexByte(" \t\r\n,:", pd.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (pd *posDecoder) position(offset int) Pos {
+	line := sort.Search(len(pd.lineStarts), func(i int) bool {
+		return pd.lineStarts[i] > offset
+	})
+	return Pos{Line: line, Column: offset - pd.lineStarts[line-1] + 1}
+}
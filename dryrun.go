@@ -0,0 +1,151 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const mapKeyPlaceholder = "[*]"
+
+// RuleMatch is the paths a rule of Differ matches, see Differ.DryRun.
+type RuleMatch struct {
+	// Kind is the kind of the rule, such as "ignore", "include", "comparator" and so on.
+	Kind string
+
+	// Rule is the regexp of the rule, or the type of Comparator and Sorter.
+	Rule string
+
+	// Paths is the paths matched by the rule, it is empty for dead rules.
+	Paths []string
+}
+
+// DryRunReport is the result of Differ.DryRun.
+type DryRunReport struct {
+	// Paths is all the paths of the sample type.
+	Paths []string
+
+	// Rules is the rules matching any path.
+	Rules []*RuleMatch
+
+	// Dead is the rules matching nothing.
+	Dead []*RuleMatch
+}
+
+func (r *DryRunReport) String() string {
+	builder := &strings.Builder{}
+	for _, rm := range r.Rules {
+		builder.WriteString(fmt.Sprintf("%s %s matches %s\n", rm.Kind, rm.Rule, strings.Join(rm.Paths, ", ")))
+	}
+	for _, rm := range r.Dead {
+		builder.WriteString(fmt.Sprintf("%s %s matches nothing\n", rm.Kind, rm.Rule))
+	}
+	return builder.String()
+}
+
+// DryRun walks the type of sample rather than values, and reports which rules of Differ match which paths,
+// and which rules match nothing, so that dead rules can be caught before comparing in production.
+// Paths are listed like Paths, recursive struct types are walked a bounded number of times on each path.
+func (d *Differ) DryRun(sample interface{}) *DryRunReport {
+	report := &DryRunReport{Paths: typePaths(reflect.TypeOf(sample), d.maxDepth)}
+	check := func(kind, rule string, match func(fieldPath string) bool) {
+		rm := &RuleMatch{Kind: kind, Rule: rule}
+		for _, p := range report.Paths {
+			if match(p) {
+				rm.Paths = append(rm.Paths, p)
+			}
+		}
+		if len(rm.Paths) > 0 {
+			report.Rules = append(report.Rules, rm)
+		} else {
+			report.Dead = append(report.Dead, rm)
+		}
+	}
+	checkRegexps := func(kind string, res []*regexp.Regexp) {
+		for _, re := range res {
+			check(kind, re.String(), re.MatchString)
+		}
+	}
+	checkRegexps("ignore", d.ignores)
+	checkRegexps("include", d.includes)
+	for _, c := range d.comparators {
+		check("comparator", fmt.Sprintf("%T", c), c.Match)
+	}
+	for _, s := range d.sorters {
+		check("sorter", fmt.Sprintf("%T", s), s.Match)
+	}
+	checkRegexps("trimSpace", d.trimSpaces)
+	for _, tt := range d.trimTags {
+		check("trim", tt.fieldRegexp.String(), tt.fieldRegexp.MatchString)
+	}
+	checkRegexps("redact", d.redacts)
+	checkRegexps("unitAware", d.unitAwares)
+	for _, t := range d.tolerances {
+		check("tolerance", t.fieldRegexp.String(), t.fieldRegexp.MatchString)
+	}
+	for _, s := range d.suppressions {
+		check("suppression", s.Path, s.fieldRegexp.MatchString)
+	}
+	for _, si := range d.scopedIgnores {
+		check(iF(si.below >= 0, "ignoreBelow", "ignoreKinds").(string), si.fieldRegexp.String(), si.fieldRegexp.MatchString)
+	}
+	return report
+}
+
+// maxStructRecursion is how many times a struct type may appear on a single path walked by typePaths.
+const maxStructRecursion = 2
+
+// Paths lists all the possible paths of the type of sample up to the max depth, which is 30 by default
+// and can be set by OptMaxDepth, so that rules can be written against a definitive list.
+// Indices of slices and arrays are rendered as "[0]", keys of maps are rendered as "[*]",
+// and every segment of a path counts as a level of depth, so that recursive types such as
+// map[string]T or []T are walked until the max depth too.
+//
+// A struct type is walked at most twice on a single path, the field recurring into it the third time
+// is listed but not walked, so that tree types such as struct{ L, R *T } do not grow exponentially,
+// while paths like "Person.Parents[0].Name" are still listed.
+func Paths(sample interface{}, opts ...Option) []string {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	return typePaths(reflect.TypeOf(sample), d.maxDepth)
+}
+
+// typePaths returns all the paths of type t up to maxDepth, recursive types are walked until maxDepth,
+// and each struct type is walked at most maxStructRecursion times on a path.
+func typePaths(t reflect.Type, maxDepth int) []string {
+	if t == nil {
+		return nil
+	}
+	var paths []string
+	onPath := make(map[reflect.Type]int)
+	var walk func(t reflect.Type, fieldPath string, depth int)
+	walk = func(t reflect.Type, fieldPath string, depth int) {
+		if depth > maxDepth {
+			return
+		}
+		paths = append(paths, fieldPath)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			if onPath[t] >= maxStructRecursion {
+				return
+			}
+			onPath[t]++
+			for i := 0; i < t.NumField(); i++ {
+				walk(t.Field(i).Type, concat(fieldPath, ".", t.Field(i).Name), depth+1)
+			}
+			onPath[t]--
+		case reflect.Slice, reflect.Array:
+			walk(t.Elem(), fieldPath+"[0]", depth+1)
+		case reflect.Map:
+			walk(t.Elem(), fieldPath+mapKeyPlaceholder, depth+1)
+		}
+	}
+	walk(t, rootTypeName(t), 0)
+	return paths
+}
@@ -0,0 +1,111 @@
+package sdiffer
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies an EnvChange.
+type ChangeKind int
+
+const (
+	// Added means the variable is only in B.
+	Added ChangeKind = iota
+
+	// Removed means the variable is only in A.
+	Removed
+
+	// Changed means the variable is in both A and B with different values.
+	Changed
+)
+
+func (ck ChangeKind) String() string {
+	switch ck {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(ck))
+}
+
+// SecretKeyRegexp matches keys whose values are redacted by CompareEnv and CompareFlags.
+var SecretKeyRegexp = regexp.MustCompile(`(?i)(secret|passw(or)?d|token|credential|private|api_?key|access_?key)`)
+
+// EnvChange is a change of an environment variable or a flag.
+type EnvChange struct {
+	Key  string
+	Kind ChangeKind
+	A    string
+	B    string
+}
+
+func (ec *EnvChange) String() string {
+	switch ec.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s=%s", ec.Key, ec.B)
+	case Removed:
+		return fmt.Sprintf("- %s=%s", ec.Key, ec.A)
+	}
+	return fmt.Sprintf("~ %s: %s => %s", ec.Key, ec.A, ec.B)
+}
+
+// ParseEnv parses environ such as os.Environ() into a map.
+func ParseEnv(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if idx := strings.Index(kv, "="); idx > 0 {
+			env[kv[:idx]] = kv[idx+1:]
+		}
+	}
+	return env
+}
+
+// CompareEnv compares two environments and returns the changes sorted by key,
+// values of keys matched by SecretKeyRegexp are redacted.
+func CompareEnv(a, b map[string]string) []*EnvChange {
+	var changes []*EnvChange
+	for k, va := range a {
+		vb, ok := b[k]
+		switch {
+		case !ok:
+			changes = append(changes, &EnvChange{Key: k, Kind: Removed, A: va})
+		case va != vb:
+			changes = append(changes, &EnvChange{Key: k, Kind: Changed, A: va, B: vb})
+		}
+	}
+	for k, vb := range b {
+		if _, ok := a[k]; !ok {
+			changes = append(changes, &EnvChange{Key: k, Kind: Added, B: vb})
+		}
+	}
+	for _, c := range changes {
+		if SecretKeyRegexp.MatchString(c.Key) {
+			c.A = iF(c.Kind == Added, "", redacted).(string)
+			c.B = iF(c.Kind == Removed, "", redacted).(string)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// CompareFlags compares the current values of all flags defined in two flag.FlagSet,
+// see CompareEnv.
+func CompareFlags(a, b *flag.FlagSet) []*EnvChange {
+	return CompareEnv(flagValues(a), flagValues(b))
+}
+
+func flagValues(fs *flag.FlagSet) map[string]string {
+	values := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
@@ -0,0 +1,18 @@
+package sdiffer
+
+// Equal reports whether a and b are equal with the semantics of Differ
+// (sorters, comparators, trims, ignores and so on can be set by opts).
+//
+// Equal runs in quiet mode, which means the comparison stops at the first diff,
+// and no diff will be collected or formatted.
+//
+// Attention:
+// Equal may panic just like Differ.Compare.
+func Equal(a, b interface{}, opts ...Option) bool {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.quiet = true
+	return d.Compare(a, b).diffCount == 0
+}
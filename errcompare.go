@@ -0,0 +1,97 @@
+package sdiffer
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+)
+
+// ErrorMode is how errors are compared, see WithErrorComparison.
+type ErrorMode int
+
+const (
+	// ErrorByMessage compares errors by Error().
+	ErrorByMessage ErrorMode = iota
+
+	// ErrorByChain compares errors by chains, two errors are equal if each of them Is the innermost error
+	// of the other, such as two errors wrapping the same sentinel error with different messages.
+	ErrorByChain
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+type errorRule struct {
+	fieldRegexp *regexp.Regexp
+	mode        ErrorMode
+}
+
+// WithErrorComparison compares values implementing error by mode instead of walking their internal fields,
+// diffs of errors are reported with their Error() as A and B. It applies to all fields if fieldPaths is empty.
+//
+// For example:
+// differ := NewDiffer().WithErrorComparison(ErrorByChain, `\.Err$`)
+func (d *Differ) WithErrorComparison(mode ErrorMode, fieldPaths ...string) *Differ {
+	if len(fieldPaths) == 0 {
+		fieldPaths = []string{".*"}
+	}
+	for _, exp := range fieldPaths {
+		d.errorRules = append(d.errorRules, &errorRule{regexp.MustCompile(exp), mode})
+	}
+	return d
+}
+
+// compareErrors compares errors a and b by the ErrorMode of fieldPath, it returns false if
+// they are not errors or either of them is nil.
+func (d *Differ) compareErrors(a, b reflect.Value, fieldPath string) (handled bool) {
+	if len(d.errorRules) == 0 || !a.Type().Implements(errorType) || !a.CanInterface() {
+		return false
+	}
+	ea, okA := a.Interface().(error)
+	eb, okB := b.Interface().(error)
+	if !okA || !okB || isNilError(a) || isNilError(b) {
+		return false
+	}
+	for _, r := range d.errorRules {
+		if !r.fieldRegexp.MatchString(fieldPath) {
+			continue
+		}
+		d.visit(fieldPath)
+		var equal bool
+		switch r.mode {
+		case ErrorByChain:
+			equal = errors.Is(ea, innermostError(eb)) && errors.Is(eb, innermostError(ea))
+		default:
+			equal = ea.Error() == eb.Error()
+		}
+		if equal {
+			d.setEqual(fieldPath, a, b)
+		} else {
+			d.setDiff(fieldPath, ea.Error(), eb.Error())
+		}
+		return true
+	}
+	return false
+}
+
+// isNilError checks if v is a nil interface or a nil pointer, or an interface holding a typed nil,
+// which can not be asked for Error().
+func isNilError(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || isNilError(v.Elem())
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// innermostError unwraps err until it wraps nothing.
+func innermostError(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
@@ -0,0 +1,27 @@
+package sdiffer
+
+import "fmt"
+
+// NotEqualError is returned when two values are expected to be equal but are not.
+type NotEqualError struct {
+	// Result is the diffs of the last comparison, it may be nil if the comparison failed.
+	Result *Result
+
+	// Report is the diff report of the last comparison.
+	Report string
+
+	// Cause is the reason why it stopped comparing, such as the error of context,
+	// or the error occurred in the last comparison.
+	Cause error
+}
+
+func (e *NotEqualError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("not equal: %v\n%s", e.Cause, e.Report)
+	}
+	return "not equal:\n" + e.Report
+}
+
+func (e *NotEqualError) Unwrap() error {
+	return e.Cause
+}
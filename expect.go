@@ -0,0 +1,43 @@
+package sdiffer
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type expectedDiff struct {
+	fieldRegexp *regexp.Regexp
+	va, vb      interface{}
+}
+
+// WithExpectedDiff declares a known and accepted difference, diffs whose path matches fieldPath
+// and values render the same as va and vb are recorded as expected diffs, see ExpectedDiffs,
+// rather than diffs, so they are excluded from Diffs and HasDiffs.
+//
+// For example:
+// differ := NewDiffer().WithExpectedDiff(`Order\.Version$`, "v1", "v2")
+func (d *Differ) WithExpectedDiff(fieldPath string, va, vb interface{}) *Differ {
+	d.expecteds = append(d.expecteds, &expectedDiff{regexp.MustCompile(fieldPath), va, vb})
+	return d
+}
+
+// ExpectedDiffs returns diffs declared by WithExpectedDiff which are found.
+func (d *Differ) ExpectedDiffs() []*Diff {
+	dfs := make([]*Diff, 0, len(d.expectedDiffs))
+	for _, df := range d.expectedDiffs {
+		dfs = append(dfs, df)
+	}
+	sortDiffs(dfs)
+	return dfs
+}
+
+// isExpectedDiff checks if the diff of fieldName is declared by WithExpectedDiff.
+func (d *Differ) isExpectedDiff(fieldName string, va, vb interface{}) bool {
+	for _, ed := range d.expecteds {
+		if ed.fieldRegexp.MatchString(fieldName) &&
+			fmt.Sprint(ed.va) == fmt.Sprint(va) && fmt.Sprint(ed.vb) == fmt.Sprint(vb) {
+			return true
+		}
+	}
+	return false
+}
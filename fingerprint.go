@@ -0,0 +1,165 @@
+package sdiffer
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a stable hash of v under the rules of Differ set by opts, see Differ.Fingerprint.
+func Fingerprint(v interface{}, opts ...Option) uint64 {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d.Fingerprint(v)
+}
+
+// Fingerprint returns a stable hash of v under the rules of Differ, so that values which Differ finds
+// no diff between are likely to have the same fingerprint, which can be used to pre-filter pairs to compare
+// or to dedupe equivalent payloads. Ignored fields are skipped, slices are sorted by Sorters,
+// strings are trimmed by WithTrimSpace and WithTrim, and numbers are rounded to their tolerances.
+//
+// Attention:
+// Values with the same fingerprint are not guaranteed to be equal, and numbers equal within a tolerance
+// may be rounded to different fingerprints if they lie on both sides of a rounding boundary.
+// Comparators and other rules without a canonical form are not applied.
+func (d *Differ) Fingerprint(v interface{}, opts ...Option) uint64 {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.Fingerprint(v)
+	}
+	h := fnv.New64a()
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		_, _ = io.WriteString(h, null)
+		return h.Sum64()
+	}
+	d.hashValue(h, rv, rootName(rv), 0)
+	return h.Sum64()
+}
+
+// hashValue writes leaves of v which are not ignored into h, each leaf is written with its path.
+func (d *Differ) hashValue(h hash.Hash64, v reflect.Value, fieldPath string, depth int) {
+	if depth > d.maxDepth {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			d.hashLeaf(h, fieldPath, null)
+			return
+		}
+		d.hashValue(h, v.Elem(), fieldPath, depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			d.hashValue(h, v.Field(i), concat(fieldPath, ".", v.Type().Field(i).Name), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			d.hashLeaf(h, fieldPath, null)
+			return
+		}
+		d.hashLeaf(h, concat(fieldPath, lengthSuffix), v.Len())
+		v = d.sortedSlice(v, fieldPath)
+		for i := 0; i < v.Len(); i++ {
+			d.hashValue(h, v.Index(i), concat(fieldPath, "[", strconv.Itoa(i), "]"), depth+1)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			d.hashLeaf(h, fieldPath, null)
+			return
+		}
+		d.hashLeaf(h, concat(fieldPath, lengthSuffix), v.Len())
+		keys := v.MapKeys()
+		paths := make(map[reflect.Value]string, len(keys))
+		for _, k := range keys {
+			paths[k] = concat(fieldPath, d.keySegment(fieldPath, k))
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return paths[keys[i]] < paths[keys[j]]
+		})
+		for _, k := range keys {
+			d.hashValue(h, v.MapIndex(k), paths[k], depth+1)
+		}
+	default:
+		d.hashLeaf(h, fieldPath, d.canonicalLeaf(v, fieldPath))
+	}
+}
+
+func (d *Differ) hashLeaf(h hash.Hash64, fieldPath string, v interface{}) {
+	if d.isSkippedField(fieldPath) {
+		return
+	}
+	_, _ = fmt.Fprintf(h, "%s=%T:%v\n", fieldPath, interfaceOf(v), v)
+}
+
+// isSkippedField checks if diffs of fieldName are not reported because of Ignore or Includes.
+func (d *Differ) isSkippedField(fieldName string) bool {
+	switch d.getDiffMode() {
+	case includeMode:
+		return !d.isIncludedField(fieldName)
+	case ignoreMode:
+		return d.isIgnoredField(fieldName)
+	}
+	return false
+}
+
+// sortedSlice returns a sorted copy of slice v if any Sorter matches fieldPath, or else v itself.
+func (d *Differ) sortedSlice(v reflect.Value, fieldPath string) reflect.Value {
+	if v.Kind() != reflect.Slice || !v.CanInterface() {
+		return v
+	}
+	for _, s := range d.sorters {
+		if s.Match(fieldPath) {
+			sorted := copySliceValue(v)
+			sortBy(sorted, s, fieldPath)
+			return sorted
+		}
+	}
+	return v
+}
+
+// canonicalLeaf returns the canonical form of leaf v, strings are trimmed by WithTrimSpace and WithTrim,
+// and numbers are rounded to multiples of the absolute tolerance, or to significant digits of the relative one.
+func (d *Differ) canonicalLeaf(v reflect.Value, fieldPath string) interface{} {
+	for _, t := range d.tolerances {
+		if !t.fieldRegexp.MatchString(fieldPath) || !t.appliesTo(v.Type()) {
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			break
+		}
+		if t.abs > 0 {
+			return math.Round(f/t.abs) * t.abs
+		}
+		if t.rel > 0 {
+			digits := int(math.Ceil(-math.Log10(t.rel)))
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', maxInt(digits, 1), 64), 64)
+		}
+		return f
+	}
+	if v.Kind() == reflect.String {
+		for _, ts := range d.trimSpaces {
+			if ts.MatchString(fieldPath) {
+				return strings.TrimSpace(v.String())
+			}
+		}
+		for _, tt := range d.trimTags {
+			if tt.fieldRegexp.MatchString(fieldPath) {
+				return tt.Trim(v.String())
+			}
+		}
+	}
+	return interfaceOf(v)
+}
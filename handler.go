@@ -0,0 +1,49 @@
+package sdiffer
+
+import "reflect"
+
+// KindHandler handles the comparison of values with a specific reflect.Kind instead of Differ,
+// see Differ.RegisterKindHandler.
+//
+// For example, treat Func fields as equal by name:
+//
+//	func(v *Visitor, a, b reflect.Value) {
+//		na, nb := runtime.FuncForPC(a.Pointer()).Name(), runtime.FuncForPC(b.Pointer()).Name()
+//		if na != nb {
+//			v.Report(ElemDiff, na, nb)
+//		}
+//	}
+type KindHandler func(v *Visitor, a, b reflect.Value)
+
+// Visitor is given to KindHandler to record diffs and compare sub-fields.
+type Visitor struct {
+	d     *Differ
+	path  string
+	depth int
+}
+
+// Path returns the field path being compared.
+func (v *Visitor) Path() string {
+	return v.path
+}
+
+// Report records a diff of the field path being compared.
+func (v *Visitor) Report(dt DiffType, va, vb interface{}) {
+	v.d.setTypedDiff(dt, v.path, va, vb)
+}
+
+// Compare compares sub-fields with Differ, subPath will be appended to the field path,
+// such as ".Value" or "[0]".
+func (v *Visitor) Compare(a, b reflect.Value, subPath string) {
+	v.d.doCompare(a, b, concat(v.path, subPath), v.depth+1)
+}
+
+// RegisterKindHandler let h handle the comparison of values with kind,
+// it overrides how Differ traverses the kind. Comparators still take precedence over h.
+func (d *Differ) RegisterKindHandler(kind reflect.Kind, h KindHandler) *Differ {
+	if d.kindHandlers == nil {
+		d.kindHandlers = make(map[reflect.Kind]KindHandler)
+	}
+	d.kindHandlers[kind] = h
+	return d
+}
@@ -0,0 +1,164 @@
+package sdiffer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Exchange is a recorded HTTP request and response.
+type Exchange struct {
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// CompareExchange compares two recorded HTTP exchanges: method, URL, status, headers and bodies.
+// Headers are compared by CompareHeader with ignoredHeaders, query strings are compared by CompareQuery,
+// and bodies are compared by Differ.CompareJSON if both of them are JSON, or else as strings.
+// JSON values changing their types, such as 1 and "x", are recorded as ElemDiffs in bodies.
+// Diffs are recorded under paths like "Request.Header[Accept]" and "Response.Body[data][0]".
+func CompareExchange(a, b *Exchange, ignoredHeaders ...string) *Differ {
+	d := NewDiffer()
+	d.compareExchange("", a, b, ignoredHeaders)
+	return d
+}
+
+// CompareHAR compares two HAR documents, entries are paired by their indices,
+// see CompareExchange for how each pair of entries is compared.
+// Diffs are recorded under paths like "Entries[0].Response.Status".
+func CompareHAR(a, b io.Reader, ignoredHeaders ...string) (*Differ, error) {
+	ea, err := readHAR(a)
+	if err != nil {
+		return nil, fmt.Errorf("read har a: %w", err)
+	}
+	eb, err := readHAR(b)
+	if err != nil {
+		return nil, fmt.Errorf("read har b: %w", err)
+	}
+	d := NewDiffer()
+	if len(ea) != len(eb) {
+		d.setTypedDiff(LengthDiff, "Entries[Length]", len(ea), len(eb))
+	}
+	for i := 0; i < minInt(len(ea), len(eb)); i++ {
+		d.compareExchange(concat("Entries[", strconv.Itoa(i), "]."), ea[i], eb[i], ignoredHeaders)
+	}
+	return d, nil
+}
+
+func (d *Differ) compareExchange(prefix string, a, b *Exchange, ignoredHeaders []string) {
+	if a.Method != b.Method {
+		d.setDiff(prefix+"Request.Method", a.Method, b.Method)
+	}
+	d.compareURL(prefix+"Request.", a.URL, b.URL)
+	d.adopt(CompareHeader(a.RequestHeader, b.RequestHeader, ignoredHeaders...), headerRoot, prefix+"Request.Header")
+	d.compareBody(prefix+"Request.Body", a.RequestBody, b.RequestBody)
+	if a.Status != b.Status {
+		d.setDiff(prefix+"Response.Status", a.Status, b.Status)
+	}
+	d.adopt(CompareHeader(a.ResponseHeader, b.ResponseHeader, ignoredHeaders...), headerRoot, prefix+"Response.Header")
+	d.compareBody(prefix+"Response.Body", a.ResponseBody, b.ResponseBody)
+}
+
+func (d *Differ) compareURL(prefix, a, b string) {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		if a != b {
+			d.setDiff(prefix+"URL", a, b)
+		}
+		return
+	}
+	qa, qb := ua.Query(), ub.Query()
+	ua.RawQuery, ub.RawQuery = "", ""
+	if ua.String() != ub.String() {
+		d.setDiff(prefix+"URL", ua.String(), ub.String())
+	}
+	d.adopt(CompareQuery(qa, qb), queryRoot, prefix+"Query")
+}
+
+func (d *Differ) compareBody(fieldPath string, a, b []byte) {
+	if json.Valid(a) && json.Valid(b) {
+		jd, err := NewDiffer().CompareJSON(a, b)
+		if err == nil {
+			d.adopt(jd, initTypeName, fieldPath)
+			return
+		}
+	}
+	if string(a) != string(b) {
+		d.setDiff(fieldPath, string(a), string(b))
+	}
+}
+
+// adopt records diffs of other into Differ, with root of their paths replaced by newRoot.
+func (d *Differ) adopt(other *Differ, root, newRoot string) {
+	for _, df := range other.Result().Diffs {
+		d.setTypedDiff(df.dt, newRoot+strings.TrimPrefix(df.name, root), df.Va(), df.Vb())
+	}
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harDocument struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string         `json:"method"`
+				URL      string         `json:"url"`
+				Headers  []harNameValue `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status  int            `json:"status"`
+				Headers []harNameValue `json:"headers"`
+				Content struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func readHAR(r io.Reader) ([]*Exchange, error) {
+	doc := &harDocument{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	exchanges := make([]*Exchange, 0, len(doc.Log.Entries))
+	for _, e := range doc.Log.Entries {
+		ex := &Exchange{
+			Method:         e.Request.Method,
+			URL:            e.Request.URL,
+			RequestHeader:  harHeader(e.Request.Headers),
+			Status:         e.Response.Status,
+			ResponseHeader: harHeader(e.Response.Headers),
+			ResponseBody:   []byte(e.Response.Content.Text),
+		}
+		if e.Request.PostData != nil {
+			ex.RequestBody = []byte(e.Request.PostData.Text)
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, nil
+}
+
+func harHeader(nvs []harNameValue) http.Header {
+	h := make(http.Header, len(nvs))
+	for _, nv := range nvs {
+		h.Add(nv.Name, nv.Value)
+	}
+	return h
+}
@@ -0,0 +1,52 @@
+package sdiffer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+)
+
+// Hasher hashes a value, values with the same hash are considered equal, see Differ.WithMapHashing.
+type Hasher func(v interface{}) uint64
+
+type mapHashing struct {
+	minLen int
+	hasher Hasher
+}
+
+// WithMapHashing makes Differ hash values of keys in both maps with at least minLen entries before comparing them,
+// and only deep-compare values whose hashes differ, which cuts comparison time for large and mostly-equal maps.
+// FNV-1a of the value printed in Go syntax is used if hasher is nil, with which values
+// holding pointers are always deep-compared since pointers are printed as addresses.
+//
+// Attention:
+// Values with the same hash are not compared at all, so hasher should be consistent with rules of Differ,
+// such as Ignore and Comparators, and equal fields inside them are not reported by WithReportEqual.
+func (d *Differ) WithMapHashing(minLen int, hasher Hasher) *Differ {
+	if hasher == nil {
+		hasher = fnvHasher
+	}
+	d.mapHashing = &mapHashing{minLen: minLen, hasher: hasher}
+	return d
+}
+
+// fnvHasher hashes v printed in Go syntax with FNV-1a.
+func fnvHasher(v interface{}) uint64 {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%#v", v)
+	return h.Sum64()
+}
+
+// hashes returns hashes of values of m by key if m should be hashed, see WithMapHashing.
+func (d *Differ) hashes(m reflect.Value) map[interface{}]uint64 {
+	mh := d.mapHashing
+	if mh == nil || m.Len() < mh.minLen || !m.CanInterface() {
+		return nil
+	}
+	hs := make(map[interface{}]uint64, m.Len())
+	iter := m.MapRange()
+	for iter.Next() {
+		hs[iter.Key().Interface()] = mh.hasher(iter.Value().Interface())
+	}
+	return hs
+}
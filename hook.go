@@ -0,0 +1,23 @@
+package sdiffer
+
+// WithPostHook adds a hook called with the final result after every comparison, such as Compare and CompareAt,
+// so that logging, metrics and persistence can be wired up in one place. Hooks are called in the order they are added,
+// after results are written into sinks.
+//
+// For example:
+// differ := NewDiffer().WithPostHook(func(res *Result) { log.Printf("%d diffs", len(res.Diffs)) })
+func (d *Differ) WithPostHook(hook func(result *Result)) *Differ {
+	d.postHooks = append(d.postHooks, hook)
+	return d
+}
+
+// runPostHooks calls hooks set by WithPostHook with the result.
+func (d *Differ) runPostHooks() {
+	if len(d.postHooks) == 0 {
+		return
+	}
+	res := d.Result()
+	for _, hook := range d.postHooks {
+		hook(res)
+	}
+}
@@ -0,0 +1,84 @@
+package sdiffer
+
+import (
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+const (
+	headerRoot = "Header"
+	queryRoot  = "Query"
+)
+
+// NoisyHeaders are headers which usually differ between two recorded HTTP exchanges.
+var NoisyHeaders = []string{"Date", "Request-Id", "X-Request-Id", "X-Trace-Id", "Traceparent", "Age", "Expires"}
+
+// CompareHeader compares two http.Header, keys are case-insensitive, and values of a key are
+// order-insensitive, headers in ignores are not compared, such as NoisyHeaders.
+// Diffs are recorded under paths like "Header[Content-Type]", keys are quoted like map keys, see KeySegment.
+func CompareHeader(a, b http.Header, ignores ...string) *Differ {
+	ignored := make(map[string]struct{}, len(ignores))
+	for _, name := range ignores {
+		ignored[textproto.CanonicalMIMEHeaderKey(name)] = struct{}{}
+	}
+	return compareMultiValues(headerRoot, normalizeMultiValues(a, ignored, true),
+		normalizeMultiValues(b, ignored, true))
+}
+
+// CompareQuery compares two url.Values, values of a key are order-insensitive,
+// and keys in ignores are not compared. Unlike CompareHeader, keys are case-sensitive.
+// Diffs are recorded under paths like "Query[page]".
+func CompareQuery(a, b url.Values, ignores ...string) *Differ {
+	ignored := make(map[string]struct{}, len(ignores))
+	for _, name := range ignores {
+		ignored[name] = struct{}{}
+	}
+	return compareMultiValues(queryRoot, normalizeMultiValues(a, ignored, false),
+		normalizeMultiValues(b, ignored, false))
+}
+
+// normalizeMultiValues copies m without ignored keys, and sorts values of each key.
+func normalizeMultiValues(m map[string][]string, ignored map[string]struct{}, canonical bool) map[string][]string {
+	res := make(map[string][]string, len(m))
+	for k, vs := range m {
+		if canonical {
+			k = textproto.CanonicalMIMEHeaderKey(k)
+		}
+		if _, ok := ignored[k]; ok {
+			continue
+		}
+		res[k] = append(res[k], vs...)
+	}
+	for _, vs := range res {
+		sort.Strings(vs)
+	}
+	return res
+}
+
+func compareMultiValues(root string, a, b map[string][]string) *Differ {
+	d := NewDiffer()
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := concat(root, KeySegment(k))
+		va, okA := a[k]
+		vb, okB := b[k]
+		if !okA || !okB {
+			d.setTypedDiff(NilDiff, path, iF(okA, va, null), iF(okB, vb, null))
+			continue
+		}
+		d.doCompare(reflect.ValueOf(va), reflect.ValueOf(vb), path, 1)
+	}
+	return d
+}
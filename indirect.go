@@ -0,0 +1,56 @@
+package sdiffer
+
+import (
+	"reflect"
+	"strconv"
+)
+
+const indirectionSuffix = "[Indirection]"
+
+// indirect dereferences multi-level pointers a and b of the same type together, until they are not pointers.
+// A nil diff is recorded if only one of them is nil at some level, and false is returned if there is
+// nothing more to compare, such as both of them are nil, they point to the same value or form a cycle.
+func (d *Differ) indirect(a, b reflect.Value, fieldPath string, depth int) (reflect.Value, reflect.Value, bool) {
+	var seen []uintptr
+	for a.Kind() == reflect.Ptr {
+		if a.IsNil() != b.IsNil() {
+			d.setNilDiffExpanded(fieldPath, a, b, depth)
+			return a, b, false
+		}
+		if a.IsNil() || a.Pointer() == b.Pointer() {
+			return a, b, false
+		}
+		for _, p := range seen {
+			if p == a.Pointer() {
+				return a, b, false
+			}
+		}
+		seen = append(seen, a.Pointer())
+		a, b = a.Elem(), b.Elem()
+	}
+	return a, b, true
+}
+
+// compareIndirection compares values a and b of interfaces which differ in types, if they are the same
+// type after dereferenced, the level of indirection is recorded as a diff and the values are compared,
+// or else false is returned.
+func (d *Differ) compareIndirection(a, b reflect.Value, fieldPath string, depth int) bool {
+	ea, la := derefAll(a)
+	eb, lb := derefAll(b)
+	if la == lb || ea.Type() != eb.Type() {
+		return false
+	}
+	d.setDiff(fieldPath+indirectionSuffix, strconv.Itoa(la), strconv.Itoa(lb))
+	d.doCompare(ea, eb, fieldPath, depth)
+	return true
+}
+
+// derefAll dereferences non-nil pointers of v and returns the value and the level of indirection.
+func derefAll(v reflect.Value) (reflect.Value, int) {
+	level := 0
+	for v.Kind() == reflect.Ptr && !v.IsNil() && level < defaultDepthLimit {
+		v = v.Elem()
+		level++
+	}
+	return v, level
+}
@@ -0,0 +1,54 @@
+package sdiffer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	writerType     = reflect.TypeOf((*io.Writer)(nil)).Elem()
+	httpClientType = reflect.TypeOf((*http.Client)(nil))
+)
+
+// WithSkippedTypes skips values of types, in addition to the infrastructure types skipped by default,
+// which are channels, *http.Client, and interfaces implementing context.Context, io.Reader or io.Writer,
+// such as io.ReadCloser. An interface type in types also skips interface types implementing it.
+//
+// For example:
+// differ := NewDiffer().WithSkippedTypes(reflect.TypeOf((*sql.DB)(nil)), reflect.TypeOf((*io.Closer)(nil)).Elem())
+func (d *Differ) WithSkippedTypes(types ...reflect.Type) *Differ {
+	d.skipTypes = append(d.skipTypes, types...)
+	return d
+}
+
+// WithInfraComparison compares values of the infrastructure types instead of skipping them,
+// types set by WithSkippedTypes are still skipped.
+func (d *Differ) WithInfraComparison() *Differ {
+	d.compareInfra = true
+	return d
+}
+
+// isSkippedType checks if values of t are skipped, see WithSkippedTypes.
+func (d *Differ) isSkippedType(t reflect.Type) bool {
+	for _, st := range d.skipTypes {
+		if t == st || (st.Kind() == reflect.Interface && t.Kind() == reflect.Interface && t.Implements(st)) {
+			return true
+		}
+	}
+	return !d.compareInfra && isInfraType(t)
+}
+
+// isInfraType checks if t is one of the infrastructure types skipped by default.
+func isInfraType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan:
+		return true
+	case reflect.Interface:
+		return t.Implements(contextType) || t.Implements(readerType) || t.Implements(writerType)
+	}
+	return t == httpClientType
+}
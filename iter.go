@@ -0,0 +1,70 @@
+package sdiffer
+
+import "sort"
+
+// Iterator iterates the diffs found in order of path without materializing them into a slice.
+//
+// For example:
+//
+//	it := differ.Iter()
+//	for it.Next() {
+//		fmt.Println(it.Value())
+//	}
+type Iterator struct {
+	diffs map[string]*Diff
+	names []string
+	i     int
+	cur   *Diff
+}
+
+// Iter returns an Iterator of the diffs found, diffs found by the following comparisons are not iterated.
+func (d *Differ) Iter() *Iterator {
+	names := make([]string, 0, len(d.diffs))
+	for name := range d.diffs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &Iterator{diffs: d.diffs, names: names}
+}
+
+// Next moves to the next diff, it returns false if there is no more diff.
+func (it *Iterator) Next() bool {
+	for it.i < len(it.names) {
+		df, ok := it.diffs[it.names[it.i]]
+		it.i++
+		if ok {
+			it.cur = df
+			return true
+		}
+	}
+	it.cur = nil
+	return false
+}
+
+// Value returns the current diff, it is nil before Next is called or after Next returns false.
+func (it *Iterator) Value() *Diff {
+	return it.cur
+}
+
+// Chunks calls fn with chunks of at most size diffs in order of path, and stops at the first error fn returns.
+func (d *Differ) Chunks(size int, fn func(chunk []*Diff) error) error {
+	if size <= 0 {
+		size = len(d.diffs)
+	}
+	it := d.Iter()
+	chunk := make([]*Diff, 0, size)
+	for it.Next() {
+		chunk = append(chunk, it.Value())
+		if len(chunk) < size {
+			continue
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+		chunk = make([]*Diff, 0, size)
+	}
+	if len(chunk) > 0 {
+		return fn(chunk)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyMatcher checks if key ka of map A matches key kb of map B, see Differ.WithKeyMatcher.
+type KeyMatcher func(ka, kb interface{}) bool
+
+type keyMatcher struct {
+	fieldRegexp *regexp.Regexp
+	match       KeyMatcher
+}
+
+// WithKeyMatcher pairs keys of maps whose path matches fieldPath by matcher, when a key of A is not in B,
+// so that values of keys which are not identical but equivalent, such as 1.0 and 1, are compared
+// instead of being reported as missing. Diffs of paired values are reported under the key of A,
+// and each key of B is paired at most once.
+//
+// For example:
+// differ := NewDiffer().WithKeyMatcher(`\.Prices$`, NumericKeyTolerance(1e-9))
+func (d *Differ) WithKeyMatcher(fieldPath string, matcher KeyMatcher) *Differ {
+	d.keyMatchers = append(d.keyMatchers, &keyMatcher{regexp.MustCompile(fieldPath), matcher})
+	return d
+}
+
+// NumericKeyTolerance matches numeric keys, or string keys which can be parsed as numbers,
+// if they differ by at most epsilon.
+func NumericKeyTolerance(epsilon float64) KeyMatcher {
+	t := &tolerance{abs: epsilon}
+	return func(ka, kb interface{}) bool {
+		fa, okA := toFloat(reflect.ValueOf(ka))
+		fb, okB := toFloat(reflect.ValueOf(kb))
+		return okA && okB && t.equals(fa, fb)
+	}
+}
+
+// TimeKeyWindow matches time.Time keys, or string keys in RFC 3339, if they are in the same window
+// after truncated to a multiple of window.
+func TimeKeyWindow(window time.Duration) KeyMatcher {
+	return func(ka, kb interface{}) bool {
+		ta, okA := toTime(ka)
+		tb, okB := toTime(kb)
+		return okA && okB && ta.Truncate(window).Equal(tb.Truncate(window))
+	}
+}
+
+// PrefixKeyMatcher matches string keys if one of them is a prefix of the other.
+func PrefixKeyMatcher() KeyMatcher {
+	return func(ka, kb interface{}) bool {
+		sa, okA := ka.(string)
+		sb, okB := kb.(string)
+		return okA && okB && (strings.HasPrefix(sa, sb) || strings.HasPrefix(sb, sa))
+	}
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		tt, err := time.Parse(time.RFC3339Nano, t)
+		return tt, err == nil
+	}
+	return time.Time{}, false
+}
+
+// matchKeys pairs keys of a which are not in b with keys of b which are not in a, by the KeyMatcher of fieldPath,
+// or by trimmed texts if the keys are strings and a trim rule matches fieldPath.
+// It returns the paired key of b by key of a, and the set of paired keys of b.
+func (d *Differ) matchKeys(a, b reflect.Value, fieldPath string) (pairs map[interface{}]reflect.Value, paired map[interface{}]struct{}) {
+	var match KeyMatcher
+	for _, km := range d.keyMatchers {
+		if km.fieldRegexp.MatchString(fieldPath) {
+			match = km.match
+			break
+		}
+	}
+	if match == nil {
+		match = d.trimKeyMatcher(a.Type().Key(), fieldPath)
+	}
+	if match == nil || !a.CanInterface() {
+		return nil, nil
+	}
+	var onlyA, onlyB []reflect.Value
+	for _, k := range a.MapKeys() {
+		if !b.MapIndex(k).IsValid() {
+			onlyA = append(onlyA, k)
+		}
+	}
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			onlyB = append(onlyB, k)
+		}
+	}
+	sortKeys := func(keys []reflect.Value) {
+		sort.Slice(keys, func(i, j int) bool {
+			return d.keySegment(fieldPath, keys[i]) < d.keySegment(fieldPath, keys[j])
+		})
+	}
+	sortKeys(onlyA)
+	sortKeys(onlyB)
+	pairs = make(map[interface{}]reflect.Value)
+	paired = make(map[interface{}]struct{})
+	for _, ka := range onlyA {
+		for _, kb := range onlyB {
+			if _, ok := paired[kb.Interface()]; ok || !match(ka.Interface(), kb.Interface()) {
+				continue
+			}
+			pairs[ka.Interface()] = kb
+			paired[kb.Interface()] = struct{}{}
+			break
+		}
+	}
+	return pairs, paired
+}
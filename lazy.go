@@ -0,0 +1,89 @@
+package sdiffer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchError is returned by CompareLazy when loading any side fails, ErrA or ErrB is nil if the side is loaded.
+type FetchError struct {
+	ErrA error
+	ErrB error
+}
+
+func (e *FetchError) Error() string {
+	var msgs []string
+	if e.ErrA != nil {
+		msgs = append(msgs, fmt.Sprintf("fetch a: %v", e.ErrA))
+	}
+	if e.ErrB != nil {
+		msgs = append(msgs, fmt.Sprintf("fetch b: %v", e.ErrB))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrA if it is not nil, or else ErrB.
+func (e *FetchError) Unwrap() error {
+	if e.ErrA != nil {
+		return e.ErrA
+	}
+	return e.ErrB
+}
+
+// Timings is the time spent on loading both sides and comparing them, see Differ.CompareLazy.
+type Timings struct {
+	FetchA  time.Duration
+	FetchB  time.Duration
+	Compare time.Duration
+}
+
+// CompareLazy loads a and b by getA and getB concurrently, and compares them like Compare.
+// If loading any side fails, nothing is compared and a *FetchError is returned, so that failures
+// of loading are told apart from diffs. The time spent on loading and comparing are recorded separately,
+// see Timings.
+func (d *Differ) CompareLazy(getA, getB Loader, opts ...Option) (*Differ, error) {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.CompareLazy(getA, getB)
+	}
+	var (
+		wg     sync.WaitGroup
+		a, b   interface{}
+		fe     FetchError
+		timing Timings
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		a, fe.ErrA = getA()
+		timing.FetchA = time.Since(start)
+	}()
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		b, fe.ErrB = getB()
+		timing.FetchB = time.Since(start)
+	}()
+	wg.Wait()
+	if fe.ErrA != nil || fe.ErrB != nil {
+		d.timings = &timing
+		return d, &fe
+	}
+	d.compare(a, b, &timing)
+	return d, nil
+}
+
+// Timings returns the time spent on the last comparison of CompareLazy, false is returned
+// if no comparison is done by CompareLazy.
+func (d *Differ) Timings() (Timings, bool) {
+	if d.timings == nil {
+		return Timings{}, false
+	}
+	return *d.timings, true
+}
@@ -0,0 +1,89 @@
+package sdiffer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxTruncatedValueLen is the max length of values in reports exceeding the limit of WithMaxReportBytes.
+const maxTruncatedValueLen = 64
+
+// WithMaxReportBytes limits the size of reports rendered by Render and String to n bytes.
+// When a report exceeds n bytes, values of diffs are truncated first, then diffs of lower severity
+// are dropped, and a summary like "... and 4,812 more diffs" is appended.
+// The severity of diffs is given by WithSeverity, and fields set by WithMustEqual are SeverityHigh by default.
+func (d *Differ) WithMaxReportBytes(n int) *Differ {
+	d.maxReportBytes = n
+	return d
+}
+
+// WithSeverity sets the severity of diffs, which decides diffs to keep in reports limited by WithMaxReportBytes.
+func (d *Differ) WithSeverity(severity SeverityFunc) *Differ {
+	d.severity = severity
+	return d
+}
+
+// severityOf returns the severity of df.
+func (d *Differ) severityOf(df *Diff) Severity {
+	if d.severity != nil {
+		return d.severity(df)
+	}
+	return iF(d.isMustEqualField(df.name), SeverityHigh, SeverityLow).(Severity)
+}
+
+// renderLimited renders dfs sorted by path into a report of at most maxReportBytes bytes, see WithMaxReportBytes.
+func (d *Differ) renderLimited(dfs []*Diff, format string) string {
+	report := d.render(dfs, format)
+	if len(report) <= d.maxReportBytes {
+		return report
+	}
+	truncated := make([]*Diff, 0, len(dfs))
+	for _, df := range dfs {
+		tdf := *df
+		tdf.setValues(truncateValue(df.Va()), truncateValue(df.Vb()))
+		truncated = append(truncated, &tdf)
+	}
+	report = d.render(truncated, format)
+	if len(report) <= d.maxReportBytes {
+		return report
+	}
+	ranked := append(make([]*Diff, 0, len(truncated)), truncated...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return d.severityOf(ranked[i]) > d.severityOf(ranked[j])
+	})
+	// estimate the number of diffs to keep by their lines, and then drop more until the report fits.
+	size, n := len(moreDiffs(len(dfs))), 0
+	for ; n < len(ranked); n++ {
+		size += len(d.formatDiff(ranked[n], format)) + 1
+		if size > d.maxReportBytes {
+			break
+		}
+	}
+	for ; n >= 0; n-- {
+		kept := append(make([]*Diff, 0, n), ranked[:n]...)
+		sortDiffs(kept)
+		report = d.render(kept, format) + moreDiffs(len(dfs)-n)
+		if len(report) <= d.maxReportBytes {
+			break
+		}
+	}
+	return report
+}
+
+// truncateValue truncates the text of v if it has more than maxTruncatedValueLen characters.
+func truncateValue(v interface{}) interface{} {
+	if r := []rune(toString(v)); len(r) > maxTruncatedValueLen {
+		return string(r[:maxTruncatedValueLen]) + "..."
+	}
+	return v
+}
+
+// moreDiffs returns the summary of n diffs dropped, such as "... and 4,812 more diffs".
+func moreDiffs(n int) string {
+	text := strconv.Itoa(n)
+	for i := len(text) - 3; i > 0; i -= 3 {
+		text = text[:i] + "," + text[i:]
+	}
+	return fmt.Sprintf("... and %s more diffs\n", text)
+}
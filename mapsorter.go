@@ -0,0 +1,169 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// MapEntry is an entry of a map, see MapSorter.
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// MapSorter sorts entries of maps before comparison to compare maps as ordered lists of entries,
+// which is needed when the order of entries matters, such as config precedence lists modeled as maps.
+type MapSorter interface {
+
+	// Match checks if a map field should be compared in order.
+	Match(fieldPath string) bool
+
+	// Less reports whether entry a comes before entry b, entries should be totally ordered by it.
+	Less(a, b MapEntry) bool
+}
+
+type mapSorterFunc struct {
+	fieldRegexp *regexp.Regexp
+	less        func(a, b MapEntry) bool
+}
+
+func (ms *mapSorterFunc) Match(fieldPath string) bool {
+	return ms.fieldRegexp.MatchString(fieldPath)
+}
+
+func (ms *mapSorterFunc) Less(a, b MapEntry) bool {
+	return ms.less(a, b)
+}
+
+// MapSorterFunc returns a MapSorter ordering entries of maps matching fieldPath by less.
+//
+// For example:
+// sorter := MapSorterFunc(`\.Layers$`, func(a, b MapEntry) bool { return a.Value.(int) < b.Value.(int) })
+func MapSorterFunc(fieldPath string, less func(a, b MapEntry) bool) MapSorter {
+	return &mapSorterFunc{regexp.MustCompile(fieldPath), less}
+}
+
+// KeyOrder returns a MapSorter ordering entries of maps matching fieldPath by key,
+// numbers are ordered by value, and other keys are ordered by their text.
+func KeyOrder(fieldPath string) MapSorter {
+	return MapSorterFunc(fieldPath, func(a, b MapEntry) bool {
+		return keyLess(a.Key, b.Key)
+	})
+}
+
+// WithMapSorter compares maps matching s as ordered lists of entries sorted by s,
+// the i-th entries of two maps are compared under "[i].Key" and "[i].Value" of the path,
+// and entries beyond the shorter length are recorded as MissingDiff under "[i]".
+//
+// For example:
+// differ := NewDiffer().WithMapSorter(KeyOrder(`\.Precedence$`))
+func (d *Differ) WithMapSorter(s MapSorter) *Differ {
+	d.mapSorters = append(d.mapSorters, s)
+	return d
+}
+
+type mapEntry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+// compareOrderedMap compares maps a and b as ordered lists of entries sorted by s,
+// it returns false if the entries can not be sorted, see WithSorterRecover.
+func (d *Differ) compareOrderedMap(a, b reflect.Value, s MapSorter, fieldPath string, depth int) bool {
+	ea, ok := d.sortEntries(a, s, fieldPath)
+	if !ok {
+		return false
+	}
+	eb, ok := d.sortEntries(b, s, fieldPath)
+	if !ok {
+		return false
+	}
+	for i := 0; i < minInt(len(ea), len(eb)) && !d.stopped; i++ {
+		entryPath := concat(fieldPath, "[", strconv.Itoa(i), "]")
+		d.doCompare(ea[i].key, eb[i].key, concat(entryPath, ".Key"), depth)
+		d.doCompare(ea[i].value, eb[i].value, concat(entryPath, ".Value"), depth)
+	}
+	if d.withinLengthTolerance(a, b, fieldPath) {
+		return true
+	}
+	for i := len(eb); i < len(ea) && !d.stopped; i++ {
+		d.setEntryDiff(MissingDiff, fieldPath, concat(fieldPath, "[", strconv.Itoa(i), "]"), renderEntry(ea[i]), missing)
+	}
+	for i := len(ea); i < len(eb) && !d.stopped; i++ {
+		d.setEntryDiff(MissingDiff, fieldPath, concat(fieldPath, "[", strconv.Itoa(i), "]"), missing, renderEntry(eb[i]))
+	}
+	return true
+}
+
+// sortEntries returns entries of map m sorted by s, it panics with a *SorterError if s.Less panics,
+// or records a SorterDiff and returns false if WithSorterRecover is called.
+func (d *Differ) sortEntries(m reflect.Value, s MapSorter, fieldPath string) (entries []mapEntry, ok bool) {
+	var x, y MapEntry
+	defer func() {
+		if r := recover(); r != nil {
+			err := &SorterError{Path: fieldPath, A: x, B: y, Reason: r}
+			if !d.sorterRecover {
+				panic(err)
+			}
+			d.setTypedDiff(SorterDiff, fieldPath, err.Error(), err.Error())
+			entries, ok = nil, false
+		}
+	}()
+	keys := m.MapKeys()
+	entries = make([]mapEntry, 0, len(keys))
+	publics := make([]MapEntry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, mapEntry{k, m.MapIndex(k)})
+	}
+	// sort by key segments first so that the order is stable for entries Less does not order.
+	sort.Slice(entries, func(i, j int) bool {
+		return d.keySegment(fieldPath, entries[i].key) < d.keySegment(fieldPath, entries[j].key)
+	})
+	for _, e := range entries {
+		publics = append(publics, MapEntry{interfaceOf(e.key), interfaceOf(e.value)})
+	}
+	sort.Stable(&entrySorter{entries, publics, func(a, b MapEntry) bool {
+		x, y = a, b
+		return s.Less(a, b)
+	}})
+	return entries, true
+}
+
+// entrySorter sorts entries and their public forms together.
+type entrySorter struct {
+	entries []mapEntry
+	publics []MapEntry
+	less    func(a, b MapEntry) bool
+}
+
+func (es *entrySorter) Len() int {
+	return len(es.entries)
+}
+
+func (es *entrySorter) Less(i, j int) bool {
+	return es.less(es.publics[i], es.publics[j])
+}
+
+func (es *entrySorter) Swap(i, j int) {
+	es.entries[i], es.entries[j] = es.entries[j], es.entries[i]
+	es.publics[i], es.publics[j] = es.publics[j], es.publics[i]
+}
+
+// keyLess orders numbers by value, and other keys by their text.
+func keyLess(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isNumberKind(va) && isNumberKind(vb) {
+		fa, _ := toFloat(va)
+		fb, _ := toFloat(vb)
+		return fa < fb
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// renderEntry renders e as "key: value" for MissingDiff.
+func renderEntry(e mapEntry) string {
+	return concat(renderMissing(e.key), ": ", renderMissing(e.value))
+}
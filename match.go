@@ -0,0 +1,70 @@
+package sdiffer
+
+import "regexp"
+
+// WithFullMatch makes patterns of Ignore and Includes match whole paths instead of any part of them,
+// so that a pattern of a field does not match fields whose names start with it by accident.
+// Patterns already set are converted as well.
+//
+// For example:
+// differ := NewDiffer().WithFullMatch().Ignore(`.*\.Id`) // ignores Order.Id but not Order.IdempotencyKey
+func (d *Differ) WithFullMatch() *Differ {
+	if d.fullMatch {
+		return d
+	}
+	d.fullMatch = true
+	d.convertPatterns(fullMatchPattern)
+	return d
+}
+
+// WithCaseInsensitive makes patterns of Ignore and Includes match paths case-insensitively,
+// like adding (?i) to each of them. Patterns already set are converted as well.
+func (d *Differ) WithCaseInsensitive() *Differ {
+	if d.foldCase {
+		return d
+	}
+	d.foldCase = true
+	d.convertPatterns(foldCasePattern)
+	return d
+}
+
+func fullMatchPattern(expr string) string {
+	return concat("^(?:", expr, ")$")
+}
+
+func foldCasePattern(expr string) string {
+	return concat("(?i)", expr)
+}
+
+// pattern converts expr of Ignore and Includes by the matching semantics of Differ.
+func (d *Differ) pattern(expr string) string {
+	if d.fullMatch {
+		expr = fullMatchPattern(expr)
+	}
+	if d.foldCase {
+		expr = foldCasePattern(expr)
+	}
+	return expr
+}
+
+// compilePatterns compiles exprs of Ignore and Includes by the matching semantics of Differ.
+func (d *Differ) compilePatterns(exprs []string) ([]*regexp.Regexp, error) {
+	patterns := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		patterns = append(patterns, d.pattern(expr))
+	}
+	return compileRegexps(patterns)
+}
+
+// convertPatterns recompiles patterns of ignores and includes converted by convert.
+func (d *Differ) convertPatterns(convert func(expr string) string) {
+	recompile := func(res []*regexp.Regexp) []*regexp.Regexp {
+		converted := make([]*regexp.Regexp, 0, len(res))
+		for _, re := range res {
+			converted = append(converted, regexp.MustCompile(convert(re.String())))
+		}
+		return converted
+	}
+	d.ignores = recompile(d.ignores)
+	d.includes = recompile(d.includes)
+}
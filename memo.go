@@ -0,0 +1,41 @@
+package sdiffer
+
+import "reflect"
+
+// pointerPair is the key of subtrees memoized by WithMemoization.
+type pointerPair struct {
+	a, b uintptr
+	t    reflect.Type
+}
+
+// WithMemoization makes Differ remember pairs of pointers whose targets are found equal,
+// and skip comparing them again when the same pair appears elsewhere in the tree, such as shared references.
+//
+// Attention:
+// Memoized subtrees are considered equal wherever they appear, so rules set by paths, such as Ignore,
+// should apply alike to all paths of them, and equal fields of skipped subtrees are not reported by WithReportEqual.
+func (d *Differ) WithMemoization() *Differ {
+	d.memoized = true
+	return d
+}
+
+// compareMemoized compares a and b, the targets of pointers pa and pb, skipping them if they are memoized.
+func (d *Differ) compareMemoized(pa, pb, a, b reflect.Value, fieldPath string, depth int) {
+	if !d.memoized {
+		d.doCompare(a, b, fieldPath, depth)
+		return
+	}
+	key := pointerPair{a: pa.Pointer(), b: pb.Pointer(), t: pa.Type()}
+	if _, ok := d.memo[key]; ok {
+		return
+	}
+	before := d.diffCount
+	d.doCompare(a, b, fieldPath, depth)
+	if d.diffCount != before || d.stopped {
+		return
+	}
+	if d.memo == nil {
+		d.memo = make(map[pointerPair]struct{}, 16)
+	}
+	d.memo[key] = struct{}{}
+}
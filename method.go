@@ -0,0 +1,67 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+type methodRule struct {
+	fieldRegexp *regexp.Regexp
+	name        string
+	keepFields  bool
+}
+
+// WithMethod compare the results of method instead of the raw fields of the values whose path matches fieldPath,
+// method must take no argument, and the first result is compared under path "<fieldPath>.<method>()".
+// Values without the method are compared as usual.
+//
+// For example:
+// differ.WithMethod(`Order\.Items\[[0-9]+\]$`, "Checksum")
+func (d *Differ) WithMethod(fieldPath string, method string) *Differ {
+	d.methods = append(d.methods, &methodRule{regexp.MustCompile(fieldPath), method, false})
+	return d
+}
+
+// WithMethodAndFields works like WithMethod, but the raw fields are compared too.
+func (d *Differ) WithMethodAndFields(fieldPath string, method string) *Differ {
+	d.methods = append(d.methods, &methodRule{regexp.MustCompile(fieldPath), method, true})
+	return d
+}
+
+// compareMethods compares results of methods matched, it returns true if the raw fields
+// do not need to be compared.
+func (d *Differ) compareMethods(a, b reflect.Value, fieldPath string, depth int) (handled bool) {
+	for _, m := range d.methods {
+		suffix := concat(".", m.name, "()")
+		if !m.fieldRegexp.MatchString(fieldPath) || strings.HasSuffix(fieldPath, suffix) {
+			continue
+		}
+		ma, mb := findMethod(a, m.name), findMethod(b, m.name)
+		if !ma.IsValid() || !mb.IsValid() {
+			continue
+		}
+		d.doCompare(ma.Call(nil)[0], mb.Call(nil)[0], concat(fieldPath, suffix), depth+1)
+		return !m.keepFields
+	}
+	return false
+}
+
+// findMethod finds a method with no argument and at least one result of v,
+// an invalid reflect.Value is returned if it is not found.
+func findMethod(v reflect.Value, name string) (method reflect.Value) {
+	if !v.CanInterface() {
+		return
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return
+	}
+	method = v.MethodByName(name)
+	if !method.IsValid() && v.CanAddr() {
+		method = v.Addr().MethodByName(name)
+	}
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() == 0 {
+		return reflect.Value{}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package sdiffer
+
+import (
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MoneyComparator compares money-like values as exact decimals, so that "1,234.50", "1234.5"
+// and 1234.5 are treated as equal. Integers can be taken as amounts in minor units such as cents
+// by setting scales with WithScale, e.g. 123450 with scale 2 equals to "1234.50".
+// It applies to strings, ints, uints and floats, values which are not money-like are compared as they are.
+//
+// For example:
+// differ := NewDiffer().WithComparator(NewMoneyComparator(`Amount$`).WithScale(2, 0))
+type MoneyComparator struct {
+	fieldRegexp    *regexp.Regexp
+	scaleA, scaleB int
+}
+
+// NewMoneyComparator creates a MoneyComparator for fields whose path matches fieldPath.
+func NewMoneyComparator(fieldPath string) *MoneyComparator {
+	return &MoneyComparator{fieldRegexp: regexp.MustCompile(fieldPath)}
+}
+
+// WithScale sets the number of decimal places of integers of a and b, for example,
+// WithScale(2, 0) compares integer cents of a with decimal dollars of b.
+func (mc *MoneyComparator) WithScale(scaleA, scaleB int) *MoneyComparator {
+	mc.scaleA, mc.scaleB = scaleA, scaleB
+	return mc
+}
+
+func (mc *MoneyComparator) pattern() string {
+	return mc.fieldRegexp.String()
+}
+
+func (mc *MoneyComparator) Match(fieldPath string) bool {
+	return mc.fieldRegexp.MatchString(fieldPath)
+}
+
+func (mc *MoneyComparator) Equals(a, b interface{}) (DiffType, interface{}, interface{}) {
+	ra, okA := parseMoney(a, mc.scaleA)
+	rb, okB := parseMoney(b, mc.scaleB)
+	if okA && okB {
+		if ra.Cmp(rb) == 0 {
+			return NoDiff, nil, nil
+		}
+		return ElemDiff, a, b
+	}
+	if reflect.DeepEqual(a, b) {
+		return NoDiff, nil, nil
+	}
+	return ElemDiff, a, b
+}
+
+// parseMoney parses a money-like value into an exact decimal, integers are divided by 10^scale.
+func parseMoney(v interface{}, scale int) (*big.Rat, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return scaleDown(new(big.Rat).SetInt64(rv.Int()), scale), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return scaleDown(new(big.Rat).SetFrac(new(big.Int).SetUint64(rv.Uint()), big.NewInt(1)), scale), true
+	case reflect.Float32, reflect.Float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()))
+	case reflect.String:
+		s := strings.Map(func(r rune) rune {
+			if r == ',' || unicode.IsSpace(r) || unicode.Is(unicode.Sc, r) {
+				return -1
+			}
+			return r
+		}, rv.String())
+		if s == "" {
+			return nil, false
+		}
+		return new(big.Rat).SetString(s)
+	}
+	return nil, false
+}
+
+func scaleDown(r *big.Rat, scale int) *big.Rat {
+	if scale <= 0 {
+		return r
+	}
+	return r.Quo(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+}
@@ -0,0 +1,56 @@
+package sdiffer
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrMustEqual is the Cause of the NotEqualError returned by CompareE
+// when any field set by WithMustEqual differs.
+var ErrMustEqual = errors.New("must-equal field differs")
+
+// WithMustEqual marks fields whose path matches any of fieldPaths as contract-critical, which must be equal.
+// Diffs of them are still recorded as usual, and can be told apart by MustEqualViolated or CompareE.
+func (d *Differ) WithMustEqual(fieldPaths ...string) *Differ {
+	for _, exp := range fieldPaths {
+		d.mustEquals = append(d.mustEquals, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// MustEqualViolated checks if any field set by WithMustEqual differs.
+func (d *Differ) MustEqualViolated() bool {
+	return d.violated
+}
+
+// CompareE compares a and b like Compare but returns the result as an error, nil is returned
+// if no diff is found, or else a *NotEqualError whose Cause is the first *CompareError if any
+// Comparator fails, or ErrMustEqual if any field set by WithMustEqual differs,
+// so that callers can hard-fail only on contract-critical fields:
+//
+//	err := differ.CompareE(a, b)
+//	if errors.Is(err, sdiffer.ErrMustEqual) {
+//		return err
+//	}
+func (d *Differ) CompareE(a, b interface{}, opts ...Option) error {
+	cd := d.Compare(a, b, opts...)
+	if !cd.HasDiffs() {
+		return nil
+	}
+	nee := &NotEqualError{Result: cd.Result(), Report: cd.String()}
+	if len(cd.errs) > 0 {
+		nee.Cause = cd.errs[0]
+	} else if cd.violated {
+		nee.Cause = ErrMustEqual
+	}
+	return nee
+}
+
+func (d *Differ) isMustEqualField(fieldName string) bool {
+	for _, me := range d.mustEquals {
+		if me.MatchString(fieldName) {
+			return true
+		}
+	}
+	return false
+}
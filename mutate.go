@@ -0,0 +1,197 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Mutation is a copy of a sample with a single leaf changed, see Differ.Mutations.
+type Mutation struct {
+	// Path is the path of the leaf changed.
+	Path string
+
+	// Value is the mutated copy of the sample, which is of the same type as the sample.
+	Value interface{}
+}
+
+// ValidationFailure is a mutation not reported as expected, see Differ.ValidateMutations.
+type ValidationFailure struct {
+	// Path is the path of the leaf changed by the mutation.
+	Path string
+
+	// Ignored is whether Path is excluded by the rules of Differ, in which case nothing is expected to be reported.
+	Ignored bool
+
+	// Reported is the paths of diffs reported for the mutation.
+	Reported []string
+}
+
+func (f *ValidationFailure) Error() string {
+	if f.Ignored {
+		return fmt.Sprintf("mutation of %s is ignored, but %v are reported", f.Path, f.Reported)
+	}
+	return fmt.Sprintf("mutation of %s is reported as %v", f.Path, f.Reported)
+}
+
+// Mutations generates copies of sample with exactly one leaf changed in each of them: booleans are negated,
+// numbers are incremented and strings are appended with "~". Only exported fields, non-nil pointers and
+// interfaces, and existing elements of slices, arrays and maps are mutated, so sample should be populated.
+// sample is not changed, the copies share the parts of sample which are not on the path changed.
+func (d *Differ) Mutations(sample interface{}) []*Mutation {
+	rv := reflect.ValueOf(sample)
+	if !rv.IsValid() {
+		return nil
+	}
+	var ms []*Mutation
+	d.mutate(rv, rootName(rv), 0, func(fieldPath string, mutated reflect.Value) {
+		ms = append(ms, &Mutation{Path: fieldPath, Value: mutated.Interface()})
+	})
+	return ms
+}
+
+// ValidateMutations compares sample with each of its Mutations, and returns the mutations which are not reported
+// as exactly the path changed, or are reported though the path is excluded by Ignore or Includes,
+// so that rule configurations can be checked automatically against changes of models in tests.
+// Sinks, hooks and streams of Differ are not called, and the results of Differ are not changed.
+//
+// For example:
+// suite.Empty(NewDiffer().Ignore(`\.UpdatedAt$`).ValidateMutations(order))
+func (d *Differ) ValidateMutations(sample interface{}, opts ...Option) []*ValidationFailure {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.ValidateMutations(sample)
+	}
+	var failures []*ValidationFailure
+	for _, m := range d.Mutations(sample) {
+		cd := d.clone()
+		cd.sinks, cd.postHooks, cd.stream, cd.tracer = nil, nil, nil, nil
+		cd.maxDiffs = 0
+		cd.Compare(sample, m.Value)
+		reported := make([]string, 0, len(cd.diffs))
+		for path := range cd.diffs {
+			reported = append(reported, path)
+		}
+		sort.Strings(reported)
+		ignored := !d.reportsField(m.Path)
+		if ignored && len(reported) == 0 || !ignored && len(reported) == 1 && reported[0] == m.Path {
+			continue
+		}
+		failures = append(failures, &ValidationFailure{Path: m.Path, Ignored: ignored, Reported: reported})
+	}
+	return failures
+}
+
+// reportsField checks if fieldName is reported according to includes and ignores, like isReportedField
+// but without counting hits of rules.
+func (d *Differ) reportsField(fieldName string) bool {
+	switch d.getDiffMode() {
+	case includeMode:
+		return d.isIncludedField(fieldName)
+	case ignoreMode:
+		return !d.isIgnoredField(fieldName)
+	}
+	return true
+}
+
+// mutate calls yield with each copy of v with one leaf changed, and the path of the leaf.
+func (d *Differ) mutate(v reflect.Value, fieldPath string, depth int, yield func(fieldPath string, mutated reflect.Value)) {
+	if depth > d.maxDepth || !v.CanInterface() {
+		return
+	}
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		d.mutate(v.Elem(), fieldPath, depth+1, func(p string, elem reflect.Value) {
+			out := reflect.New(t.Elem())
+			out.Elem().Set(elem)
+			yield(p, out)
+		})
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		d.mutate(v.Elem(), fieldPath, depth+1, func(p string, elem reflect.Value) {
+			out := reflect.New(t).Elem()
+			out.Set(elem)
+			yield(p, out)
+		})
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			i := i
+			d.mutate(v.Field(i), concat(fieldPath, ".", t.Field(i).Name), depth+1, func(p string, field reflect.Value) {
+				out := reflect.New(t).Elem()
+				out.Set(v)
+				out.Field(i).Set(field)
+				yield(p, out)
+			})
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			i := i
+			d.mutate(v.Index(i), concat(fieldPath, "[", strconv.Itoa(i), "]"), depth+1, func(p string, elem reflect.Value) {
+				var out reflect.Value
+				if t.Kind() == reflect.Slice {
+					out = reflect.MakeSlice(t, v.Len(), v.Len())
+					reflect.Copy(out, v)
+				} else {
+					out = reflect.New(t).Elem()
+					out.Set(v)
+				}
+				out.Index(i).Set(elem)
+				yield(p, out)
+			})
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			k := k
+			d.mutate(v.MapIndex(k), concat(fieldPath, d.keySegment(fieldPath, k)), depth+1, func(p string, elem reflect.Value) {
+				out := reflect.MakeMapWithSize(t, v.Len())
+				for _, key := range v.MapKeys() {
+					out.SetMapIndex(key, v.MapIndex(key))
+				}
+				out.SetMapIndex(k, elem)
+				yield(p, out)
+			})
+		}
+	default:
+		if mutated, ok := mutateLeaf(v); ok {
+			yield(fieldPath, mutated)
+		}
+	}
+}
+
+// mutateLeaf returns a copy of leaf v which is different from v, it returns false if v is not a leaf.
+func mutateLeaf(v reflect.Value) (reflect.Value, bool) {
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Bool:
+		out.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		out.SetUint(v.Uint() + 1)
+	case reflect.Float32, reflect.Float64:
+		// a large float does not change when incremented, negate it instead.
+		if out.SetFloat(v.Float() + 1); out.Float() == v.Float() {
+			out.SetFloat(-v.Float())
+		}
+	case reflect.Complex64, reflect.Complex128:
+		out.SetComplex(v.Complex() + 1)
+	case reflect.String:
+		out.SetString(v.String() + "~")
+	default:
+		return v, false
+	}
+	return out, true
+}
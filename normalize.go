@@ -0,0 +1,119 @@
+package sdiffer
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Normalize returns a canonical copy of v under the rules of Differ set by opts, see Differ.Normalize.
+func Normalize(v interface{}, opts ...Option) interface{} {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d.Normalize(v)
+}
+
+// Normalize returns a canonical copy of v with the transformations of Differ applied, without comparing:
+// ignored fields, including structs, slices and maps as a whole, are set to zero values, slices are sorted by Sorters, strings are trimmed by
+// WithTrimSpace and WithTrim, numbers are rounded to their tolerances, NULL sql.Null* fields set by
+// WithNullAsZero become valid zero values, and nil pointers, slices and maps become empty ones
+// if WithNilExpansion is called. It suits writing canonical forms into golden files, or hashing them.
+//
+// v is not changed, and fields which can not be set, such as unexported ones, are copied as they are.
+func (d *Differ) Normalize(v interface{}, opts ...Option) interface{} {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.Normalize(v)
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil
+	}
+	return d.normalize(rv, rootName(rv), 0).Interface()
+}
+
+// normalize returns a canonical copy of v, see Normalize.
+func (d *Differ) normalize(v reflect.Value, fieldPath string, depth int) reflect.Value {
+	if depth > d.maxDepth || !v.CanInterface() {
+		return v
+	}
+	t := v.Type()
+	// ignores are checked before descending, so that an ignored struct, slice or map is zeroed as a whole.
+	if d.getDiffMode() == ignoreMode && d.isIgnoredField(fieldPath) {
+		return reflect.Zero(t)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return iF(d.expandNil, nonNilZero(t), v).(reflect.Value)
+		}
+		out := reflect.New(t.Elem())
+		out.Elem().Set(d.normalize(v.Elem(), fieldPath, depth+1))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(t).Elem()
+		out.Set(d.normalize(v.Elem(), fieldPath, depth+1))
+		return out
+	case reflect.Struct:
+		out := reflect.New(t).Elem()
+		out.Set(v)
+		if isSQLNullType(t) {
+			if valid := out.FieldByName("Valid"); d.isNullAsZeroField(fieldPath) && valid.IsValid() && !valid.Bool() {
+				valid.SetBool(true)
+			}
+			return out
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := out.Field(i); f.CanSet() {
+				f.Set(d.normalize(v.Field(i), concat(fieldPath, ".", t.Field(i).Name), depth+1))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return iF(d.expandNil, nonNilZero(t), v).(reflect.Value)
+		}
+		v = d.sortedSlice(v, fieldPath)
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(d.normalize(v.Index(i), concat(fieldPath, "[", strconv.Itoa(i), "]"), depth+1))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(d.normalize(v.Index(i), concat(fieldPath, "[", strconv.Itoa(i), "]"), depth+1))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return iF(d.expandNil, nonNilZero(t), v).(reflect.Value)
+		}
+		out := reflect.MakeMapWithSize(t, v.Len())
+		for _, k := range v.MapKeys() {
+			out.SetMapIndex(k, d.normalize(v.MapIndex(k), concat(fieldPath, d.keySegment(fieldPath, k)), depth+1))
+		}
+		return out
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return v
+	}
+	if d.getDiffMode() == includeMode && !d.isIncludedField(fieldPath) {
+		return reflect.Zero(t)
+	}
+	return toKind(reflect.ValueOf(d.canonicalLeaf(v, fieldPath)), t)
+}
+
+// toKind converts leaf v into type t, numbers are formatted if t is a string type.
+func toKind(v reflect.Value, t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.String && v.Kind() == reflect.Float64 {
+		return reflect.ValueOf(strconv.FormatFloat(v.Float(), 'g', -1, 64)).Convert(t)
+	}
+	return v.Convert(t)
+}
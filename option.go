@@ -0,0 +1,363 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// Option configures a Differ, it is mainly used by package-level helpers such as Equal.
+type Option func(d *Differ)
+
+// OptIgnore works like Differ.Ignore.
+func OptIgnore(regexps ...string) Option {
+	return func(d *Differ) {
+		d.Ignore(regexps...)
+	}
+}
+
+// OptExtraIgnores appends fields that do not need to be compared to the existing ones,
+// unlike OptIgnore which replaces them.
+func OptExtraIgnores(regexps ...string) Option {
+	return func(d *Differ) {
+		for _, expr := range regexps {
+			d.ignores = append(d.ignores, regexp.MustCompile(d.pattern(expr)))
+		}
+	}
+}
+
+// OptIncludes works like Differ.Includes.
+func OptIncludes(regexps ...string) Option {
+	return func(d *Differ) {
+		d.Includes(regexps...)
+	}
+}
+
+// OptComparator works like Differ.WithComparator.
+func OptComparator(c Comparator) Option {
+	return func(d *Differ) {
+		d.WithComparator(c)
+	}
+}
+
+// OptSorter works like Differ.WithSorter.
+func OptSorter(s Sorter) Option {
+	return func(d *Differ) {
+		d.WithSorter(s)
+	}
+}
+
+// OptTrim works like Differ.WithTrim.
+func OptTrim(fieldPath string, cutset string) Option {
+	return func(d *Differ) {
+		d.WithTrim(fieldPath, cutset)
+	}
+}
+
+// OptTrimSpace works like Differ.WithTrimSpace.
+func OptTrimSpace(fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithTrimSpace(fieldPaths...)
+	}
+}
+
+// OptMaxDepth works like Differ.WithMaxDepth.
+func OptMaxDepth(depth int) Option {
+	return func(d *Differ) {
+		d.WithMaxDepth(depth)
+	}
+}
+
+// OptMaxDiffs works like Differ.WithMaxDiffs.
+func OptMaxDiffs(n int) Option {
+	return func(d *Differ) {
+		d.WithMaxDiffs(n)
+	}
+}
+
+// OptLabel works like Differ.WithLabel.
+func OptLabel(label string) Option {
+	return func(d *Differ) {
+		d.WithLabel(label)
+	}
+}
+
+// OptVisitedPaths works like Differ.WithVisitedPaths.
+func OptVisitedPaths() Option {
+	return func(d *Differ) {
+		d.WithVisitedPaths()
+	}
+}
+
+// OptTolerance works like Differ.WithTolerance.
+func OptTolerance(fieldPath string, epsilon float64) Option {
+	return func(d *Differ) {
+		d.WithTolerance(fieldPath, epsilon)
+	}
+}
+
+// OptPostHook works like Differ.WithPostHook.
+func OptPostHook(hook func(result *Result)) Option {
+	return func(d *Differ) {
+		d.WithPostHook(hook)
+	}
+}
+
+// OptTimeWindowMatch works like Differ.WithTimeWindowMatch.
+func OptTimeWindowMatch(fieldPath string, window time.Duration, timeOf func(elem interface{}) time.Time) Option {
+	return func(d *Differ) {
+		d.WithTimeWindowMatch(fieldPath, window, timeOf)
+	}
+}
+
+// OptKeyMatcher works like Differ.WithKeyMatcher.
+func OptKeyMatcher(fieldPath string, matcher KeyMatcher) Option {
+	return func(d *Differ) {
+		d.WithKeyMatcher(fieldPath, matcher)
+	}
+}
+
+// OptDeltaThreshold works like Differ.WithDeltaThreshold.
+func OptDeltaThreshold(fieldPath string, absDelta, percent float64) Option {
+	return func(d *Differ) {
+		d.WithDeltaThreshold(fieldPath, absDelta, percent)
+	}
+}
+
+// OptChangeClassification works like Differ.WithChangeClassification.
+func OptChangeClassification() Option {
+	return func(d *Differ) {
+		d.WithChangeClassification()
+	}
+}
+
+// OptComparisonID works like Differ.WithComparisonID.
+func OptComparisonID(id string) Option {
+	return func(d *Differ) {
+		d.WithComparisonID(id)
+	}
+}
+
+// OptSourceInfo works like Differ.WithSourceInfo.
+func OptSourceInfo(infoA, infoB map[string]interface{}) Option {
+	return func(d *Differ) {
+		d.WithSourceInfo(infoA, infoB)
+	}
+}
+
+// OptMaxReportBytes works like Differ.WithMaxReportBytes.
+func OptMaxReportBytes(n int) Option {
+	return func(d *Differ) {
+		d.WithMaxReportBytes(n)
+	}
+}
+
+// OptMemoization works like Differ.WithMemoization.
+func OptMemoization() Option {
+	return func(d *Differ) {
+		d.WithMemoization()
+	}
+}
+
+// OptMapHashing works like Differ.WithMapHashing.
+func OptMapHashing(minLen int, hasher Hasher) Option {
+	return func(d *Differ) {
+		d.WithMapHashing(minLen, hasher)
+	}
+}
+
+// OptJSONPath works like Differ.WithJSONPath.
+func OptJSONPath() Option {
+	return func(d *Differ) {
+		d.WithJSONPath()
+	}
+}
+
+// OptRootName works like Differ.WithRootName.
+func OptRootName(root string) Option {
+	return func(d *Differ) {
+		d.WithRootName(root)
+	}
+}
+
+// OptPathSeparator works like Differ.WithPathSeparator.
+func OptPathSeparator(sep, open, close string) Option {
+	return func(d *Differ) {
+		d.WithPathSeparator(sep, open, close)
+	}
+}
+
+// OptCrossField works like Differ.WithCrossField.
+func OptCrossField(pathA, pathB string) Option {
+	return func(d *Differ) {
+		d.WithCrossField(pathA, pathB)
+	}
+}
+
+// OptSection works like Differ.WithSection.
+func OptSection(name string, fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithSection(name, fieldPaths...)
+	}
+}
+
+// OptComparatorSuffix works like Differ.WithComparatorSuffix.
+func OptComparatorSuffix() Option {
+	return func(d *Differ) {
+		d.WithComparatorSuffix()
+	}
+}
+
+// OptPathLabels works like Differ.WithPathLabels.
+func OptPathLabels(labels map[string]string) Option {
+	return func(d *Differ) {
+		d.WithPathLabels(labels)
+	}
+}
+
+// OptShiftDetection works like Differ.WithShiftDetection.
+func OptShiftDetection(fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithShiftDetection(fieldPaths...)
+	}
+}
+
+// OptSliceContext works like Differ.WithSliceContext.
+func OptSliceContext(n int) Option {
+	return func(d *Differ) {
+		d.WithSliceContext(n)
+	}
+}
+
+// OptReportEqual works like Differ.WithReportEqual.
+func OptReportEqual() Option {
+	return func(d *Differ) {
+		d.WithReportEqual()
+	}
+}
+
+// OptSuppression works like Differ.WithSuppression.
+func OptSuppression(ss ...Suppression) Option {
+	return func(d *Differ) {
+		d.WithSuppression(ss...)
+	}
+}
+
+// OptExpectedDiff works like Differ.WithExpectedDiff.
+func OptExpectedDiff(fieldPath string, va, vb interface{}) Option {
+	return func(d *Differ) {
+		d.WithExpectedDiff(fieldPath, va, vb)
+	}
+}
+
+// OptMustEqual works like Differ.WithMustEqual.
+func OptMustEqual(fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithMustEqual(fieldPaths...)
+	}
+}
+
+// OptDateFormat works like Differ.WithDateFormat.
+func OptDateFormat(fieldPath string, layouts ...string) Option {
+	return func(d *Differ) {
+		d.WithDateFormat(fieldPath, layouts...)
+	}
+}
+
+// OptRelativeTolerance works like Differ.WithRelativeTolerance.
+func OptRelativeTolerance(fieldPath string, ratio float64) Option {
+	return func(d *Differ) {
+		d.WithRelativeTolerance(fieldPath, ratio)
+	}
+}
+
+// OptLengthTolerance works like Differ.WithLengthTolerance.
+func OptLengthTolerance(fieldPath string, n int) Option {
+	return func(d *Differ) {
+		d.WithLengthTolerance(fieldPath, n)
+	}
+}
+
+// OptMapSorter works like Differ.WithMapSorter.
+func OptMapSorter(s MapSorter) Option {
+	return func(d *Differ) {
+		d.WithMapSorter(s)
+	}
+}
+
+// OptStringPosition works like Differ.WithStringPosition.
+func OptStringPosition() Option {
+	return func(d *Differ) {
+		d.WithStringPosition()
+	}
+}
+
+// OptErrorComparison works like Differ.WithErrorComparison.
+func OptErrorComparison(mode ErrorMode, fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithErrorComparison(mode, fieldPaths...)
+	}
+}
+
+// OptSyncMapSnapshot works like Differ.WithSyncMapSnapshot.
+func OptSyncMapSnapshot(fieldPaths ...string) Option {
+	return func(d *Differ) {
+		d.WithSyncMapSnapshot(fieldPaths...)
+	}
+}
+
+// OptSkippedTypes works like Differ.WithSkippedTypes.
+func OptSkippedTypes(types ...reflect.Type) Option {
+	return func(d *Differ) {
+		d.WithSkippedTypes(types...)
+	}
+}
+
+// OptInfraComparison works like Differ.WithInfraComparison.
+func OptInfraComparison() Option {
+	return func(d *Differ) {
+		d.WithInfraComparison()
+	}
+}
+
+// OptIgnoreBelow works like Differ.IgnoreBelow.
+func OptIgnoreBelow(fieldPath string, depth int) Option {
+	return func(d *Differ) {
+		d.IgnoreBelow(fieldPath, depth)
+	}
+}
+
+// OptIgnoreKinds works like Differ.IgnoreKinds.
+func OptIgnoreKinds(fieldPath string, kinds ...reflect.Kind) Option {
+	return func(d *Differ) {
+		d.IgnoreKinds(fieldPath, kinds...)
+	}
+}
+
+// OptFullMatch works like Differ.WithFullMatch.
+func OptFullMatch() Option {
+	return func(d *Differ) {
+		d.WithFullMatch()
+	}
+}
+
+// OptCaseInsensitive works like Differ.WithCaseInsensitive.
+func OptCaseInsensitive() Option {
+	return func(d *Differ) {
+		d.WithCaseInsensitive()
+	}
+}
+
+// OptRecover works like Differ.WithRecover.
+func OptRecover() Option {
+	return func(d *Differ) {
+		d.WithRecover()
+	}
+}
+
+// OptSorterRecover works like Differ.WithSorterRecover.
+func OptSorterRecover() Option {
+	return func(d *Differ) {
+		d.WithSorterRecover()
+	}
+}
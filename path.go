@@ -0,0 +1,238 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// jsonPathKeyEscaper escapes map keys quoted in JSONPath, see Differ.WithJSONPath.
+var jsonPathKeyEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// keySegment renders the path segment of key k of the map at mapPath.
+func (d *Differ) keySegment(mapPath string, k reflect.Value) string {
+	return d.quoteKey(d.formatMapKey(mapPath, k))
+}
+
+// quoteKey renders the path segment of map key, which is quoted like ['key'] in JSONPath mode,
+// see KeySegment for the segment in other modes.
+func (d *Differ) quoteKey(key string) string {
+	if d.pathStyle != nil && d.pathStyle.jsonPath {
+		return concat("['", jsonPathKeyEscaper.Replace(key), "']")
+	}
+	return KeySegment(key)
+}
+
+// KeySegment returns the path segment of map key as it is in paths of diffs, which is [key],
+// or ["key"] quoted like a Go string if key contains any of `[]."'\` or non-printable characters,
+// so that the segment can be located unambiguously. Patterns for keys can be built with it, such as:
+//
+//	differ := NewDiffer().Ignore(regexp.QuoteMeta(sdiffer.KeySegment("a.b")))
+func KeySegment(key string) string {
+	if strings.ContainsAny(key, "[].'\"\\") || strings.IndexFunc(key, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return concat("[", strconv.Quote(key), "]")
+	}
+	return concat("[", key, "]")
+}
+
+// unquoteKey returns the map key of token quoted by quoteKey, token is returned as it is if not quoted.
+func unquoteKey(token string) string {
+	if len(token) >= 2 && token[0] == '"' && token[len(token)-1] == '"' {
+		if key, err := strconv.Unquote(token); err == nil {
+			return key
+		}
+	}
+	if len(token) < 2 || token[0] != '\'' || token[len(token)-1] != '\'' {
+		return token
+	}
+	builder := &strings.Builder{}
+	for i := 1; i < len(token)-1; i++ {
+		if token[i] == '\\' && i+1 < len(token)-1 {
+			i++
+		}
+		builder.WriteByte(token[i])
+	}
+	return builder.String()
+}
+
+// rootName returns the root of field paths of v, which is the name of its type,
+// or "$" if the type is unnamed.
+func rootName(v reflect.Value) string {
+	return rootTypeName(v.Type())
+}
+
+func rootTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return iF(isStringBlank(t.Name()), initTypeName, t.Name()).(string)
+}
+
+// CompareAt navigates a and b to fieldPath first, and compares only the subtree there.
+// fieldPath uses the same syntax as paths of diffs, such as "Person.Parents[0].Loc",
+// and paths of diffs found are rooted at fieldPath, so that a single differing field can be re-checked cheaply.
+//
+// Attention:
+// CompareAt panics if fieldPath does not exist in a or b, like Compare.
+func (d *Differ) CompareAt(a, b interface{}, fieldPath string, opts ...Option) *Differ {
+	if len(opts) > 0 {
+		cd := d.clone()
+		for _, opt := range opts {
+			opt(cd)
+		}
+		return cd.CompareAt(a, b, fieldPath)
+	}
+	va, err := d.resolvePath(reflect.ValueOf(a), fieldPath)
+	if err != nil {
+		panic(err.Error())
+	}
+	vb, err := d.resolvePath(reflect.ValueOf(b), fieldPath)
+	if err != nil {
+		panic(err.Error())
+	}
+	if va.Type() != vb.Type() {
+		typeMismatchPanic(va.Type(), vb.Type())
+	}
+	d.rootType = reflect.TypeOf(a)
+	if d.tracer != nil {
+		defer d.trace(fieldPath)()
+	}
+	d.newComparisonID()
+	d.streamLabel()
+	d.doCompare(va, vb, fieldPath, 0)
+	d.streamFlush()
+	d.writeSinks()
+	d.runPostHooks()
+	return d
+}
+
+// Get returns the value at fieldPath of value, fieldPath uses the same syntax as paths of diffs,
+// so that the live values at paths of a diff report can be fetched, for example:
+//
+//	v, err := sdiffer.Get(person, "Person.Parents[0].Loc.Name")
+//
+// The comparator suffix is ignored, and the length is returned for paths ending with "[Length]".
+// Map keys are matched by their canonical form, see Differ.WithKeyStringer.
+func Get(value interface{}, fieldPath string) (interface{}, error) {
+	p := strings.TrimSuffix(fieldPath, useComparatorSuffix)
+	length := strings.HasSuffix(p, lengthSuffix)
+	p = strings.TrimSuffix(p, lengthSuffix)
+	v, err := NewDiffer().resolvePath(reflect.ValueOf(value), p)
+	if err != nil {
+		return nil, err
+	}
+	if length {
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
+			return v.Len(), nil
+		}
+		return nil, fmt.Errorf("path %s: %s has no length", fieldPath, p)
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("path %s: unexported field", fieldPath)
+	}
+	return v.Interface(), nil
+}
+
+// resolvePath navigates v to fieldPath, map keys are matched by their rendered form.
+func (d *Differ) resolvePath(v reflect.Value, fieldPath string) (reflect.Value, error) {
+	if !v.IsValid() {
+		return v, fmt.Errorf("path %s: value invalid", fieldPath)
+	}
+	root := rootName(v)
+	if !strings.HasPrefix(fieldPath, root) {
+		return v, fmt.Errorf("path %s: root must be %s", fieldPath, root)
+	}
+	cur := root
+	rest := fieldPath[len(root):]
+	for rest != "" {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return v, fmt.Errorf("path %s: nil at %s", fieldPath, cur)
+			}
+			v = v.Elem()
+		}
+		var token string
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			token, rest = rest[1:end+1], rest[end+1:]
+			if v.Kind() != reflect.Struct {
+				return v, fmt.Errorf("path %s: %s is not a struct", fieldPath, cur)
+			}
+			f := v.FieldByName(token)
+			if !f.IsValid() {
+				return v, fmt.Errorf("path %s: no field %s in %s", fieldPath, token, cur)
+			}
+			v = f
+		case '[':
+			end := closingBracket(rest)
+			if end < 0 {
+				return v, fmt.Errorf("path %s: unclosed bracket after %s", fieldPath, cur)
+			}
+			token, rest = unquoteKey(rest[1:end]), rest[end+1:]
+			elem, err := d.index(v, token, cur)
+			if err != nil {
+				return v, fmt.Errorf("path %s: %v", fieldPath, err)
+			}
+			v = elem
+		default:
+			return v, fmt.Errorf("path %s: unexpected %q after %s", fieldPath, rest[0], cur)
+		}
+		cur = fieldPath[:len(fieldPath)-len(rest)]
+	}
+	return v, nil
+}
+
+// index returns the element of slice, array or map v with the index or key rendered as token.
+func (d *Differ) index(v reflect.Value, token, cur string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= v.Len() {
+			return v, fmt.Errorf("index %s out of range of %s", token, cur)
+		}
+		return v.Index(i), nil
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if d.formatMapKey(cur, k) == token {
+				return v.MapIndex(k), nil
+			}
+		}
+		return v, fmt.Errorf("no key %s in %s", token, cur)
+	}
+	return v, fmt.Errorf("%s is not a slice, array or map", cur)
+}
+
+// closingBracket returns the index of the bracket closing s[0], nested brackets
+// and brackets inside quoted keys are skipped.
+func closingBracket(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			if i == 0 || s[i-1] != '[' {
+				continue
+			}
+			for q := s[i]; i+1 < len(s) && s[i+1] != q; i++ {
+				if s[i+1] == '\\' {
+					i++
+				}
+			}
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
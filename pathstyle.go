@@ -0,0 +1,142 @@
+package sdiffer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// jsonPathLength replaces the length suffix in JSONPath mode.
+const jsonPathLength = ".length()"
+
+// pathStyle is the syntax of paths of diffs reported, see WithRootName and WithPathSeparator.
+type pathStyle struct {
+	root    string
+	renamed bool
+	sep     string
+	open    string
+	close   string
+
+	// jsonPath makes paths valid JSONPath expressions, see WithJSONPath.
+	jsonPath bool
+}
+
+func (d *Differ) ensurePathStyle() *pathStyle {
+	if d.pathStyle == nil {
+		d.pathStyle = &pathStyle{sep: ".", open: "[", close: "]"}
+	}
+	return d.pathStyle
+}
+
+// WithRootName replaces the root of paths of diffs reported, which is the name of the type compared or "$",
+// with root, the leading separator is dropped if root is empty. For example, with root "a":
+// Person.Parents[0].Name => a.Parents[0].Name
+//
+// Ignore, Includes and other patterns still match the canonical paths.
+func (d *Differ) WithRootName(root string) *Differ {
+	ps := d.ensurePathStyle()
+	ps.root, ps.renamed = root, true
+	return d
+}
+
+// WithPathSeparator replaces the separator of fields and the brackets of indices and keys of
+// paths of diffs reported, so that paths can match the notation expected by downstream systems.
+// For example, with sep ".", open "." and close "":
+// Person.Parents[0].Name => Person.Parents.0.Name
+//
+// Ignore, Includes and other patterns still match the canonical paths.
+func (d *Differ) WithPathSeparator(sep, open, close string) *Differ {
+	ps := d.ensurePathStyle()
+	ps.sep, ps.open, ps.close = sep, open, close
+	return d
+}
+
+// WithJSONPath makes all paths of diffs valid JSONPath expressions, the root is "$",
+// map keys are quoted like ['a.b'] and any other segment which is not an identifier or an index is quoted too.
+// For example:
+// Person.Parents[0].Name => $.Parents[0].Name
+// Person.Tags[a.b] => $.Tags['a.b']
+// Person.Tags[Length] => $.Tags.length()
+//
+// Ignore, Includes and other patterns match paths with quoted map keys but rooted at the type name.
+func (d *Differ) WithJSONPath() *Differ {
+	ps := d.ensurePathStyle()
+	ps.root, ps.renamed, ps.jsonPath = initTypeName, true, true
+	ps.sep, ps.open, ps.close = ".", "[", "]"
+	return d
+}
+
+// stylePath renders canonical fieldPath with the path style set by WithRootName and WithPathSeparator.
+func (d *Differ) stylePath(fieldPath string) string {
+	ps := d.pathStyle
+	if ps == nil {
+		return fieldPath
+	}
+	end := strings.IndexAny(fieldPath, ".[")
+	if end < 0 {
+		end = len(fieldPath)
+	}
+	builder := &strings.Builder{}
+	builder.WriteString(iF(ps.renamed, ps.root, fieldPath[:end]).(string))
+	rest := fieldPath[end:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end = strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			token := rest[1 : end+1]
+			rest = rest[end+1:]
+			if ps.jsonPath && !isIdentifier(token) {
+				builder.WriteString(d.quoteKey(token))
+				continue
+			}
+			if builder.Len() > 0 {
+				builder.WriteString(ps.sep)
+			}
+			builder.WriteString(token)
+		default:
+			if ps.jsonPath && rest == lengthSuffix {
+				builder.WriteString(jsonPathLength)
+				return builder.String()
+			}
+			end = closingBracket(rest)
+			if rest[0] != '[' || end < 0 {
+				builder.WriteString(rest)
+				return builder.String()
+			}
+			token := rest[1:end]
+			rest = rest[end+1:]
+			if ps.jsonPath && !isIndex(token) && !strings.HasPrefix(token, "'") {
+				builder.WriteString(d.quoteKey(token))
+				continue
+			}
+			if builder.Len() > 0 {
+				builder.WriteString(ps.open)
+			} else {
+				builder.WriteString(strings.TrimPrefix(ps.open, ps.sep))
+			}
+			builder.WriteString(token)
+			builder.WriteString(ps.close)
+		}
+	}
+	return builder.String()
+}
+
+func isIdentifier(token string) bool {
+	for i, c := range token {
+		if !(c == '_' || unicode.IsLetter(c) || i > 0 && unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return token != ""
+}
+
+func isIndex(token string) bool {
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return token != ""
+}
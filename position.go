@@ -0,0 +1,73 @@
+package sdiffer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// excerptRadius is the number of runes around the first differing rune in excerpts of StringPosition.
+const excerptRadius = 10
+
+// StringPosition is where two differing strings first differ.
+type StringPosition struct {
+	// Index is the index of the first differing rune, which is the length of the shorter string
+	// if it is a prefix of the other.
+	Index int `json:"index"`
+
+	// ExcerptA and ExcerptB are runes of A and B around Index, "..." marks the runes cut off.
+	ExcerptA string `json:"excerptA"`
+	ExcerptB string `json:"excerptB"`
+}
+
+// WithStringPosition appends the index of the first differing rune and excerpts around it
+// to report lines of diffs of strings, so that a one-character difference in long strings
+// is easy to find, such as ` (at rune 2: "ok" vs "okay")`.
+func (d *Differ) WithStringPosition() *Differ {
+	d.positioned = true
+	return d
+}
+
+// StringPosition returns where A and B first differ if they are differing strings.
+func (d *Diff) StringPosition() (StringPosition, bool) {
+	if d.dt != ElemDiff {
+		return StringPosition{}, false
+	}
+	va, vb := reflect.ValueOf(d.Va()), reflect.ValueOf(d.Vb())
+	if va.Kind() != reflect.String || vb.Kind() != reflect.String || va.String() == vb.String() {
+		return StringPosition{}, false
+	}
+	ra, rb := []rune(va.String()), []rune(vb.String())
+	i := 0
+	for i < len(ra) && i < len(rb) && ra[i] == rb[i] {
+		i++
+	}
+	return StringPosition{Index: i, ExcerptA: excerpt(ra, i), ExcerptB: excerpt(rb, i)}, true
+}
+
+// excerpt returns runes of rs within excerptRadius around i.
+func excerpt(rs []rune, i int) string {
+	start, end := maxInt(i-excerptRadius, 0), minInt(i+excerptRadius+1, len(rs))
+	if start > end {
+		start = end
+	}
+	s := string(rs[start:end])
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(rs) {
+		s += "..."
+	}
+	return s
+}
+
+// positionSuffix returns the suffix of report lines of df if WithStringPosition is called.
+func (d *Differ) positionSuffix(df *Diff) string {
+	if !d.positioned {
+		return ""
+	}
+	pos, ok := df.StringPosition()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (at rune %d: %q vs %q)", pos.Index, pos.ExcerptA, pos.ExcerptB)
+}
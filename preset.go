@@ -0,0 +1,120 @@
+package sdiffer
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// Preset is a profile of Differ configuration for a specific kind of documents decoded from JSON,
+// such as OpenAPI specs or Terraform state and plan.
+type Preset struct {
+	// SetPaths are regexps of arrays which are sets, they are compared ignoring order.
+	SetPaths []string
+
+	// Ignores are regexps of fields not compared, such as vendor extensions.
+	Ignores []string
+
+	// Breaking are rules to tell if a diff is a breaking change, see Classify.
+	Breaking []func(df *Diff) bool
+}
+
+// OpenAPIPreset compares OpenAPI specs, vendor extensions "x-*" are ignored, and
+// required, enum, tags, security, servers and parameters are compared as sets.
+// Removing paths, operations or properties, and adding required fields are breaking changes.
+var OpenAPIPreset = &Preset{
+	SetPaths: []string{`\[(required|enum|tags|security|servers|parameters)\]$`},
+	Ignores:  []string{`\[x-[^\]]*\]`},
+	Breaking: []func(df *Diff) bool{
+		BreakingRemoval(`^\$\[paths\]\[[^\]]+\](\[[a-z]+\])?$`),
+		BreakingRemoval(`\[properties\]\[[^\]]+\]$`),
+		BreakingRule(`\[required\](\[Length\]|\[\d+\])$`, LengthDiff, ElemDiff),
+		BreakingRule(`\[type\]$`, ElemDiff),
+	},
+}
+
+// TerraformPreset compares Terraform state or plan in JSON, metadata such as timestamp,
+// serial and lineage are ignored, and depends_on of resources and module calls are compared as sets.
+var TerraformPreset = &Preset{
+	SetPaths: []string{`\[(resources|module_calls)\]\[[^\]]+\]\[depends_on\]$`},
+	Ignores:  []string{`^\$\[(timestamp|serial|lineage|terraform_version)\]$`},
+}
+
+// BreakingRule returns a rule for Preset.Breaking, which tells diffs whose path matches expr
+// and type is one of types are breaking changes, all types match if types is empty.
+func BreakingRule(expr string, types ...DiffType) func(df *Diff) bool {
+	r := regexp.MustCompile(expr)
+	return func(df *Diff) bool {
+		if !r.MatchString(df.name) {
+			return false
+		}
+		if len(types) == 0 {
+			return true
+		}
+		for _, dt := range types {
+			if df.dt == dt {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// BreakingRemoval returns a rule for Preset.Breaking, which tells fields whose path matches expr
+// and only exist in A, which means they are removed in B, are breaking changes.
+func BreakingRemoval(expr string) func(df *Diff) bool {
+	isNilDiff := BreakingRule(expr, NilDiff)
+	return func(df *Diff) bool {
+		return isNilDiff(df) && df.Vb() == null
+	}
+}
+
+// Option returns an Option applying the Preset to Differ, ignores of the Preset are appended.
+//
+// For example:
+// differ, err := NewDiffer().Compare(a, b, OpenAPIPreset.Option())
+func (p *Preset) Option() Option {
+	return func(d *Differ) {
+		OptExtraIgnores(p.Ignores...)(d)
+		for _, expr := range p.SetPaths {
+			d.WithSorter(&setSorter{regexp.MustCompile(expr)})
+		}
+	}
+}
+
+// Classify splits dfs into breaking and non-breaking changes by the Breaking rules.
+func (p *Preset) Classify(dfs []*Diff) (breaking, nonBreaking []*Diff) {
+	for _, df := range dfs {
+		isBreaking := false
+		for _, rule := range p.Breaking {
+			if rule(df) {
+				isBreaking = true
+				break
+			}
+		}
+		if isBreaking {
+			breaking = append(breaking, df)
+		} else {
+			nonBreaking = append(nonBreaking, df)
+		}
+	}
+	return
+}
+
+// setSorter sorts arrays of any JSON values by their canonical JSON text.
+type setSorter struct {
+	match *regexp.Regexp
+}
+
+func (ss *setSorter) pattern() string {
+	return ss.match.String()
+}
+
+func (ss *setSorter) Match(fieldPath string) bool {
+	return ss.match.MatchString(fieldPath)
+}
+
+func (ss *setSorter) Less(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) < string(jb)
+}
@@ -0,0 +1,60 @@
+package sdiffer
+
+import "reflect"
+
+// primitive is a tagged union of a value of primitive kind, it is stored in Diff instead of
+// an interface so that recording diffs of primitives does not allocate, and it is boxed
+// into an interface only when the value of Diff is read.
+// typ is the tag, the value is held in i for bool and signed integers, u for unsigned integers,
+// f for floats and s for strings. A zero primitive with nil typ holds no value.
+type primitive struct {
+	typ reflect.Type
+	i   int64
+	u   uint64
+	f   float64
+	s   string
+}
+
+// primitiveOf returns v as a primitive, it returns false if v is not of primitive kind
+// or can not be interfaced, such as an unexported field.
+func primitiveOf(v reflect.Value) (primitive, bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return primitive{}, false
+	}
+	p := primitive{typ: v.Type()}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			p.i = 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.i = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.u = v.Uint()
+	case reflect.Float32, reflect.Float64:
+		p.f = v.Float()
+	case reflect.String:
+		p.s = v.String()
+	default:
+		return primitive{}, false
+	}
+	return p, true
+}
+
+// value boxes p into an interface of its original type.
+func (p *primitive) value() interface{} {
+	v := reflect.New(p.typ).Elem()
+	switch p.typ.Kind() {
+	case reflect.Bool:
+		v.SetBool(p.i != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(p.i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(p.u)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(p.f)
+	case reflect.String:
+		v.SetString(p.s)
+	}
+	return v.Interface()
+}
@@ -0,0 +1,111 @@
+package sdiffer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Query filters diffs with chained conditions, diffs are sorted by path.
+//
+// For example:
+// dfs := differ.Query().PathMatches(`Orders`).TypeIs(NilDiff).Limit(10).Slice()
+type Query struct {
+	dfs   []*Diff
+	preds []func(df *Diff) bool
+	limit int
+}
+
+// Query returns a Query over the diffs found.
+func (d *Differ) Query() *Query {
+	dfs := d.Diffs()
+	sortDiffs(dfs)
+	return &Query{dfs: dfs}
+}
+
+// Query returns a Query over the diffs of Result.
+func (r *Result) Query() *Query {
+	return &Query{dfs: r.Diffs}
+}
+
+// Where keeps diffs which fn returns true for.
+func (q *Query) Where(fn func(df *Diff) bool) *Query {
+	q.preds = append(q.preds, fn)
+	return q
+}
+
+// PathMatches keeps diffs whose path matches expr.
+func (q *Query) PathMatches(expr string) *Query {
+	re := regexp.MustCompile(expr)
+	return q.Where(func(df *Diff) bool {
+		return re.MatchString(df.name)
+	})
+}
+
+// PathPrefix keeps diffs whose path is prefix or a path inside it.
+func (q *Query) PathPrefix(prefix string) *Query {
+	return q.Where(func(df *Diff) bool {
+		return isSubPath(df.name, prefix) || strings.HasPrefix(df.name, prefix+useComparatorSuffix)
+	})
+}
+
+// TypeIs keeps diffs of any of dts.
+func (q *Query) TypeIs(dts ...DiffType) *Query {
+	return q.Where(func(df *Diff) bool {
+		for _, dt := range dts {
+			if df.dt == dt {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Limit keeps at most n diffs, 0 means no limit.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Slice returns diffs matching all the conditions.
+func (q *Query) Slice() []*Diff {
+	var res []*Diff
+	for _, df := range q.dfs {
+		if q.limit > 0 && len(res) >= q.limit {
+			break
+		}
+		if q.match(df) {
+			res = append(res, df)
+		}
+	}
+	return res
+}
+
+// First returns the first diff matching all the conditions.
+func (q *Query) First() (*Diff, bool) {
+	for _, df := range q.dfs {
+		if q.match(df) {
+			return df, true
+		}
+	}
+	return nil, false
+}
+
+// Count returns the number of diffs matching all the conditions, regardless of Limit.
+func (q *Query) Count() int {
+	n := 0
+	for _, df := range q.dfs {
+		if q.match(df) {
+			n++
+		}
+	}
+	return n
+}
+
+func (q *Query) match(df *Diff) bool {
+	for _, pred := range q.preds {
+		if !pred(df) {
+			return false
+		}
+	}
+	return true
+}
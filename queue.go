@@ -0,0 +1,134 @@
+package sdiffer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned by Queue.Submit when the queue is full.
+var ErrQueueFull = errors.New("comparison queue is full")
+
+// ErrQueueClosed is returned when submitting to a closed Queue.
+var ErrQueueClosed = errors.New("comparison queue is closed")
+
+// QueueHandler handles the result of a comparison submitted to Queue,
+// err is not nil if the comparison panicked.
+type QueueHandler func(p Pair, res *Result, err error)
+
+// Queue runs comparisons submitted asynchronously by a pool of workers with the configuration of Differ,
+// and delivers the results to a QueueHandler.
+type Queue struct {
+	// submitted and rejected are accessed atomically, keep them 64-bit aligned.
+	submitted uint64
+	rejected  uint64
+
+	d       *Differ
+	handler QueueHandler
+	pairs   chan Pair
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue creates a Queue with workers workers and a buffer of size pairs, and starts the workers.
+// handler is called by workers concurrently.
+func NewQueue(d *Differ, workers, size int, handler QueueHandler) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	q := &Queue{
+		d:       d.clone(),
+		handler: handler,
+		pairs:   make(chan Pair, size),
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Submit enqueues a comparison of a and b without blocking, ErrQueueFull is returned
+// if the queue is full, so that callers can shed load rather than being blocked.
+func (q *Queue) Submit(a, b interface{}) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.pairs <- Pair{A: a, B: b}:
+		atomic.AddUint64(&q.submitted, 1)
+		return nil
+	default:
+		atomic.AddUint64(&q.rejected, 1)
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait enqueues a comparison of a and b, and blocks until the queue has room or ctx is done.
+func (q *Queue) SubmitWait(ctx context.Context, a, b interface{}) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.pairs <- Pair{A: a, B: b}:
+		atomic.AddUint64(&q.submitted, 1)
+		return nil
+	case <-ctx.Done():
+		atomic.AddUint64(&q.rejected, 1)
+		return ctx.Err()
+	}
+}
+
+// Len returns the number of comparisons waiting in the queue.
+func (q *Queue) Len() int {
+	return len(q.pairs)
+}
+
+// Submitted returns the number of comparisons enqueued.
+func (q *Queue) Submitted() uint64 {
+	return atomic.LoadUint64(&q.submitted)
+}
+
+// Rejected returns the number of comparisons rejected because the queue is full.
+func (q *Queue) Rejected() uint64 {
+	return atomic.LoadUint64(&q.rejected)
+}
+
+// Close stops accepting comparisons, and waits until comparisons enqueued are handled.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.pairs)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	cd := q.d.clone()
+	for p := range q.pairs {
+		cd.resetResult()
+		res, err := compareSafely(cd, p)
+		q.handler(p, res, err)
+	}
+}
+
+// compareSafely compares p with d and recovers panics as errors.
+func compareSafely(d *Differ, p Pair) (res *Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("compare: %v", r)
+		}
+	}()
+	return d.Compare(p.A, p.B).Result(), nil
+}
@@ -0,0 +1,76 @@
+package sdiffer
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Reconciliation is the result of Differ.Reconcile.
+type Reconciliation struct {
+	// OnlyInA and OnlyInB are keys of records only in a or b, sorted.
+	OnlyInA []string
+	OnlyInB []string
+
+	// Diffs are results of matched records with diffs, by key.
+	Diffs map[string]*Result
+}
+
+// HasDiffs checks if any record is unmatched or has diffs.
+func (rc *Reconciliation) HasDiffs() bool {
+	return len(rc.OnlyInA) > 0 || len(rc.OnlyInB) > 0 || len(rc.Diffs) > 0
+}
+
+// Reconcile joins two slices of records a and b by key, and compares the matched records
+// with the configuration of Differ. Differ itself is not changed.
+// If there are records with the same key in a slice, only the first one is used.
+//
+// For example:
+// differ.Reconcile(orders1, orders2, func(r interface{}) string { return r.(*Order).ID })
+func (d *Differ) Reconcile(a, b interface{}, key func(record interface{}) string) *Reconciliation {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		typeMismatchPanic(a, b)
+	}
+	if va.Kind() != reflect.Slice && va.Kind() != reflect.Array {
+		panic("records must be slice or array, got: " + va.Type().String())
+	}
+	recordsA, keysA := indexRecords(va, key)
+	recordsB, _ := indexRecords(vb, key)
+
+	rc := &Reconciliation{Diffs: make(map[string]*Result)}
+	cd := d.clone()
+	for _, k := range keysA {
+		rb, ok := recordsB[k]
+		if !ok {
+			rc.OnlyInA = append(rc.OnlyInA, k)
+			continue
+		}
+		cd.resetResult()
+		if r := cd.Compare(recordsA[k], rb).Result(); r.HasDiffs() {
+			rc.Diffs[k] = r
+		}
+	}
+	for k := range recordsB {
+		if _, ok := recordsA[k]; !ok {
+			rc.OnlyInB = append(rc.OnlyInB, k)
+		}
+	}
+	sort.Strings(rc.OnlyInA)
+	sort.Strings(rc.OnlyInB)
+	return rc
+}
+
+func indexRecords(records reflect.Value, key func(record interface{}) string) (map[string]interface{}, []string) {
+	indexed := make(map[string]interface{}, records.Len())
+	keys := make([]string, 0, records.Len())
+	for i := 0; i < records.Len(); i++ {
+		r := records.Index(i).Interface()
+		k := key(r)
+		if _, ok := indexed[k]; ok {
+			continue
+		}
+		indexed[k] = r
+		keys = append(keys, k)
+	}
+	return indexed, keys
+}
@@ -0,0 +1,53 @@
+package sdiffer
+
+import "reflect"
+
+// Record is implemented by records with a schema, such as Avro or Parquet records,
+// so that they can be compared field by field with schema field names in paths,
+// without being converted into maps first.
+type Record interface {
+	// Fields returns field names in schema order.
+	Fields() []string
+
+	// Get returns the value of the field.
+	Get(field string) interface{}
+}
+
+// compareRecord compares a and b as Record, it returns false if they are not Record.
+func (d *Differ) compareRecord(a, b reflect.Value, fieldPath string, depth int) (handled bool) {
+	if !a.CanInterface() || ((a.Kind() == reflect.Ptr || a.Kind() == reflect.Interface) && (a.IsNil() || b.IsNil())) {
+		return false
+	}
+	ra, ok := a.Interface().(Record)
+	if !ok {
+		return false
+	}
+	rb := b.Interface().(Record)
+	fieldsB := make(map[string]struct{})
+	for _, f := range rb.Fields() {
+		fieldsB[f] = struct{}{}
+	}
+	fieldsA := make(map[string]struct{})
+	for _, f := range ra.Fields() {
+		fieldsA[f] = struct{}{}
+		path := concat(fieldPath, ".", f)
+		if _, ok := fieldsB[f]; !ok {
+			d.setTypedDiff(NilDiff, path, notNull, null)
+			continue
+		}
+		va, vb := ra.Get(f), rb.Get(f)
+		if va == nil || vb == nil {
+			if (va == nil) != (vb == nil) {
+				d.setTypedDiff(NilDiff, path, iF(va == nil, null, notNull), iF(vb == nil, null, notNull))
+			}
+			continue
+		}
+		d.doCompare(reflect.ValueOf(va), reflect.ValueOf(vb), path, depth+1)
+	}
+	for _, f := range rb.Fields() {
+		if _, ok := fieldsA[f]; !ok {
+			d.setTypedDiff(NilDiff, concat(fieldPath, ".", f), null, notNull)
+		}
+	}
+	return true
+}
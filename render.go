@@ -0,0 +1,293 @@
+package sdiffer
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// tmplDiff is the data of a diff used in text/template.
+type tmplDiff struct {
+	Path  string
+	A     interface{}
+	B     interface{}
+	Type  DiffType
+	Count int
+	Text  string
+}
+
+// tmplSection is the data of a report section used in text/template.
+type tmplSection struct {
+	Name  string
+	Diffs []*tmplDiff
+}
+
+// tmplReport is the data of a report used in text/template.
+type tmplReport struct {
+	Label    string
+	Count    int
+	Diffs    []*tmplDiff
+	Sections []*tmplSection
+	SourceA  map[string]interface{}
+	SourceB  map[string]interface{}
+}
+
+var pathIndexRegexp = regexp.MustCompile(`\[[^\]]*\]`)
+
+type pathTmpl struct {
+	fieldRegexp *regexp.Regexp
+	tmpl        *template.Template
+}
+
+func newPathTmpl(exp, tmpl string) *pathTmpl {
+	return &pathTmpl{
+		fieldRegexp: regexp.MustCompile(exp),
+		tmpl:        template.Must(template.New(exp).Parse(tmpl)),
+	}
+}
+
+type section struct {
+	name  string
+	diffs []*Diff
+}
+
+// Render renders the diffs found into a report, diffs are sorted by path.
+// Render does not change Differ, so it is safe to be called repeatedly.
+//
+// format takes precedence over the templates of Differ if it is not blank,
+// it must contain exactly 3 placeholders, see WithTmpl.
+func (d *Differ) Render(format string) string {
+	dfs := append(d.Diffs(), d.EqualFields()...)
+	sortDiffs(dfs)
+	if d.maxReportBytes > 0 {
+		return d.renderLimited(dfs, format)
+	}
+	return d.render(dfs, format)
+}
+
+// render renders dfs sorted by path into a report.
+func (d *Differ) render(dfs []*Diff, format string) string {
+	bff := newBufferF()
+	if d.reportTmpl != nil {
+		d.renderReport(bff, dfs, format)
+		return bff.String()
+	}
+	if !isStringBlank(d.label) {
+		bff.sprintf("[%s]\n", d.label)
+	}
+	d.renderSourceInfo(bff)
+	if d.sectioned {
+		for _, sec := range d.groupSections(dfs) {
+			bff.sprintf("== %s ==\n", sec.name)
+			for _, df := range sec.diffs {
+				bff.sprintf("%s\n", d.formatDiff(df, format))
+			}
+		}
+		return bff.String()
+	}
+	for _, df := range dfs {
+		bff.sprintf("%s\n", d.formatDiff(df, format))
+	}
+	return bff.String()
+}
+
+func (d *Differ) formatDiff(df *Diff, format string) string {
+	if df.dt == NoDiff {
+		return correlationPrefix(df) + d.formatText(df, format) + equalSuffix
+	}
+	return correlationPrefix(df) + d.formatText(df, format) + d.changeSuffix(df) + d.positionSuffix(df) + aggregationSuffix(df)
+}
+
+// formatText formats df with format or templates of Differ.
+func (d *Differ) formatText(df *Diff, format string) string {
+	if label, ok := d.pathLabel(df.name); ok {
+		ldf := *df
+		ldf.name = label
+		df = &ldf
+	}
+	if !isStringBlank(format) {
+		return df.String(format)
+	}
+	tmpl := d.tmpl
+	for _, pt := range d.pathTmpls {
+		if pt.fieldRegexp.MatchString(df.name) {
+			tmpl = pt.tmpl
+			break
+		}
+	}
+	if tmpl == nil {
+		return df.String(d.diffTmpl)
+	}
+	buf := &bytes.Buffer{}
+	mustSuccess(func() error {
+		return tmpl.Execute(buf, newTmplDiff(df))
+	})
+	return buf.String()
+}
+
+func (d *Differ) renderReport(w io.Writer, dfs []*Diff, format string) {
+	report := &tmplReport{
+		Label:   d.label,
+		Count:   len(dfs),
+		Diffs:   make([]*tmplDiff, 0, len(dfs)),
+		SourceA: d.sourceA,
+		SourceB: d.sourceB,
+	}
+	for _, sec := range d.groupSections(dfs) {
+		ts := &tmplSection{Name: sec.name}
+		for _, df := range sec.diffs {
+			td := newTmplDiff(df)
+			td.Text = d.formatDiff(df, format)
+			ts.Diffs = append(ts.Diffs, td)
+			report.Diffs = append(report.Diffs, td)
+		}
+		report.Sections = append(report.Sections, ts)
+	}
+	mustSuccess(func() error {
+		return d.reportTmpl.Execute(w, report)
+	})
+}
+
+// WithPathLabels maps paths of diffs to human-readable labels in rendered reports,
+// such as "Order.Cstmr.Nm" to "Customer Name". A path matches a label if it equals to the key,
+// or its Tag equals to the key, in which case indices of the path are appended to the label,
+// such as "Order.Items[2].Prc" is rendered as "Item Price[2]" with the key "Order.Items.Prc".
+func (d *Differ) WithPathLabels(labels map[string]string) *Differ {
+	if d.pathLabels == nil {
+		d.pathLabels = make(map[string]string, len(labels))
+	}
+	for path, label := range labels {
+		d.pathLabels[path] = label
+	}
+	return d
+}
+
+// pathLabel returns the label of fieldPath set by WithPathLabels.
+func (d *Differ) pathLabel(fieldPath string) (string, bool) {
+	if len(d.pathLabels) == 0 {
+		return "", false
+	}
+	if label, ok := d.pathLabels[fieldPath]; ok {
+		return label, true
+	}
+	label, ok := d.pathLabels[(&Diff{name: fieldPath}).Tag()]
+	if !ok {
+		return "", false
+	}
+	return label + strings.Join(pathIndexRegexp.FindAllString(fieldPath, -1), ""), true
+}
+
+func newTmplDiff(df *Diff) *tmplDiff {
+	return &tmplDiff{
+		Path:  df.name,
+		A:     df.Va(),
+		B:     df.Vb(),
+		Type:  df.dt,
+		Count: df.count,
+	}
+}
+
+// groupSections groups diffs by sections set by WithSection or else top-level field,
+// sections and diffs are sorted by name.
+func (d *Differ) groupSections(dfs []*Diff) []*section {
+	sorted := append(make([]*Diff, 0, len(dfs)), dfs...)
+	sortDiffs(sorted)
+	var secs []*section
+	index := make(map[string]*section)
+	for _, df := range sorted {
+		name := d.sectionOf(df.name)
+		sec, ok := index[name]
+		if !ok {
+			sec = &section{name: name}
+			index[name] = sec
+			secs = append(secs, sec)
+		}
+		sec.diffs = append(sec.diffs, df)
+	}
+	sort.SliceStable(secs, func(i, j int) bool {
+		return secs[i].name < secs[j].name
+	})
+	return secs
+}
+
+type namedSection struct {
+	name    string
+	regexps []*regexp.Regexp
+}
+
+// WithSection partitions diffs whose path matches any of fieldPaths into the section named name,
+// the report groups diffs by sections, and diffs of a section can be queried by HasDiffsIn and SectionDiffs.
+// Diffs matching no section set by WithSection are grouped by top-level field, see WithSections.
+//
+// For example:
+// differ := NewDiffer().WithSection("metadata", `\.Meta\.`, `\.Headers\[`).WithSection("payload", `\.Body\.`)
+func (d *Differ) WithSection(name string, fieldPaths ...string) *Differ {
+	sec := &namedSection{name: name}
+	for _, exp := range fieldPaths {
+		sec.regexps = append(sec.regexps, regexp.MustCompile(exp))
+	}
+	d.sections = append(d.sections, sec)
+	d.sectioned = true
+	return d
+}
+
+// HasDiffsIn checks if any diff of section name is found.
+func (d *Differ) HasDiffsIn(name string) bool {
+	for _, df := range d.diffs {
+		if d.sectionOf(df.name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SectionDiffs returns diffs of section name sorted by path.
+func (d *Differ) SectionDiffs(name string) []*Diff {
+	var dfs []*Diff
+	for _, df := range d.diffs {
+		if d.sectionOf(df.name) == name {
+			dfs = append(dfs, df)
+		}
+	}
+	sortDiffs(dfs)
+	return dfs
+}
+
+// sectionOf returns the section of fieldPath, which is the first section set by WithSection
+// matching it, or else the top-level field.
+func (d *Differ) sectionOf(fieldPath string) string {
+	for _, sec := range d.sections {
+		for _, re := range sec.regexps {
+			if re.MatchString(fieldPath) {
+				return sec.name
+			}
+		}
+	}
+	return topLevelField(fieldPath)
+}
+
+// topLevelField returns the first field name after the root of fieldPath.
+// For example:
+// Person.Schools[0].Name => Schools
+// $[key].Name => [key]
+func topLevelField(fieldPath string) string {
+	idx := strings.IndexAny(fieldPath, ".[")
+	if idx < 0 {
+		return fieldPath
+	}
+	rest := fieldPath[idx:]
+	if rest[0] == '[' {
+		if end := closingBracket(rest); end > 0 {
+			return rest[:end+1]
+		}
+		return rest
+	}
+	rest = rest[1:]
+	if end := strings.IndexAny(rest, ".["); end > 0 {
+		return rest[:end]
+	}
+	return rest
+}
@@ -0,0 +1,165 @@
+package sdiffer
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Result is a snapshot of diffs found by Differ, it is independent of Differ,
+// so it will not be changed by the following comparisons of Differ.
+type Result struct {
+	Label string
+
+	// ComparisonID is the ID of the comparison, see Differ.WithComparisonID.
+	ComparisonID string
+
+	// SourceA and SourceB are metadata of where A and B come from, see Differ.WithSourceInfo.
+	SourceA map[string]interface{}
+	SourceB map[string]interface{}
+
+	// Diffs is sorted by path.
+	Diffs []*Diff
+
+	// Expected is diffs declared by Differ.WithExpectedDiff, sorted by path.
+	Expected []*Diff
+
+	// Equal is fields found equal when Differ.WithReportEqual is called, sorted by path.
+	Equal []*Diff
+
+	// Timings is the time spent on loading and comparing by Differ.CompareLazy, nil for other comparisons.
+	Timings *Timings
+
+	// Conflicts is entries of the same path which disagree in results merged by Merge, sorted by path.
+	Conflicts []*Conflict
+}
+
+// Result returns the snapshot of the diffs found.
+func (d *Differ) Result() *Result {
+	dfs := d.Diffs()
+	sortDiffs(dfs)
+	res := &Result{
+		Label:        d.label,
+		ComparisonID: d.compareID,
+		SourceA:      d.sourceA,
+		SourceB:      d.sourceB,
+		Diffs:        dfs,
+	}
+	if len(d.expectedDiffs) > 0 {
+		res.Expected = d.ExpectedDiffs()
+	}
+	res.Equal = d.EqualFields()
+	if d.timings != nil {
+		timings := *d.timings
+		res.Timings = &timings
+	}
+	return res
+}
+
+// HasDiffs checks if any diff is found.
+func (r *Result) HasDiffs() bool {
+	return len(r.Diffs) > 0
+}
+
+// FindDiff find diff with name.
+func (r *Result) FindDiff(fieldName string) (df *Diff, ok bool) {
+	idx := sort.Search(len(r.Diffs), func(i int) bool {
+		return r.Diffs[i].name >= fieldName
+	})
+	if idx < len(r.Diffs) && r.Diffs[idx].name == fieldName {
+		return r.Diffs[idx], true
+	}
+	return nil, false
+}
+
+// CompareResults compares two sets of diffs, e.g. the diffs of today's run and yesterday's,
+// and returns diffs only in curr as appeared and diffs only in prev as resolved.
+// Diffs are matched by field path, and results are sorted by field path.
+func CompareResults(prev, curr []*Diff) (appeared, resolved []*Diff) {
+	prevSet := make(map[string]struct{}, len(prev))
+	for _, df := range prev {
+		prevSet[df.name] = struct{}{}
+	}
+	currSet := make(map[string]struct{}, len(curr))
+	for _, df := range curr {
+		currSet[df.name] = struct{}{}
+		if _, ok := prevSet[df.name]; !ok {
+			appeared = append(appeared, df)
+		}
+	}
+	for _, df := range prev {
+		if _, ok := currSet[df.name]; !ok {
+			resolved = append(resolved, df)
+		}
+	}
+	sortDiffs(appeared)
+	sortDiffs(resolved)
+	return
+}
+
+func sortDiffs(dfs []*Diff) {
+	sort.Slice(dfs, func(i, j int) bool {
+		return dfs[i].name < dfs[j].name
+	})
+}
+
+// Conflict is a pair of entries of the same path which disagree, see Result.Merge.
+type Conflict struct {
+	Path string
+
+	// Kept is the entry kept in the merged result, Dropped is the other one.
+	Kept    *Diff
+	Dropped *Diff
+}
+
+// Merge unions diffs of other into Result, e.g. diffs of different shards of the same object compared in parallel.
+// Entries are de-duplicated by path, and entries of the same path which disagree on types or values,
+// or are found different in one result but equal in the other, are flagged as Conflicts,
+// in which case the diff found different, or else the entry of Result is kept.
+func (r *Result) Merge(other *Result) *Result {
+	if isStringBlank(r.Label) {
+		r.Label = other.Label
+	}
+	r.Conflicts = append(r.Conflicts, other.Conflicts...)
+	diffs := r.mergeDiffs(r.Diffs, other.Diffs)
+	equals := r.mergeDiffs(r.Equal, other.Equal)
+	r.Equal = r.Equal[:0]
+	for _, df := range equals {
+		if kept, ok := diffs[df.name]; ok {
+			r.Conflicts = append(r.Conflicts, &Conflict{Path: df.name, Kept: kept, Dropped: df})
+			continue
+		}
+		r.Equal = append(r.Equal, df)
+	}
+	r.Diffs = r.Diffs[:0]
+	for _, df := range diffs {
+		r.Diffs = append(r.Diffs, df)
+	}
+	sortDiffs(r.Diffs)
+	sortDiffs(r.Equal)
+	expected := r.mergeDiffs(r.Expected, other.Expected)
+	r.Expected = r.Expected[:0]
+	for _, df := range expected {
+		r.Expected = append(r.Expected, df)
+	}
+	sortDiffs(r.Expected)
+	sort.SliceStable(r.Conflicts, func(i, j int) bool {
+		return r.Conflicts[i].Path < r.Conflicts[j].Path
+	})
+	return r
+}
+
+// mergeDiffs unions dfs and others by path, conflicts are recorded into Result.
+func (r *Result) mergeDiffs(dfs, others []*Diff) map[string]*Diff {
+	merged := make(map[string]*Diff, len(dfs)+len(others))
+	for _, df := range append(append([]*Diff{}, dfs...), others...) {
+		kept, ok := merged[df.name]
+		if !ok {
+			merged[df.name] = df
+			continue
+		}
+		if kept.dt != df.dt || !reflect.DeepEqual(kept.Va(), df.Va()) || !reflect.DeepEqual(kept.Vb(), df.Vb()) {
+			r.Conflicts = append(r.Conflicts, &Conflict{Path: df.name, Kept: kept, Dropped: df})
+		}
+	}
+	return merged
+}
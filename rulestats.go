@@ -0,0 +1,70 @@
+package sdiffer
+
+import "fmt"
+
+// RuleStat is how many times a rule of Differ matched, see Differ.RuleStats.
+type RuleStat struct {
+	// Kind is the kind of the rule, which is "ignore", "include", "comparator" or "sorter".
+	Kind string
+
+	// Rule is the regexp of the rule, or the regexp of Comparator and Sorter if they are built from one,
+	// such as NewMoneyComparator, or else their String() or type.
+	Rule string
+
+	// Hits is the number of times the rule matched.
+	Hits int
+}
+
+type ruleKey struct {
+	kind  string
+	index int
+}
+
+// hit counts a match of the index-th rule of kind.
+func (d *Differ) hit(kind string, index int) {
+	if d.ruleHits == nil {
+		d.ruleHits = make(map[ruleKey]int)
+	}
+	d.ruleHits[ruleKey{kind, index}]++
+}
+
+// RuleStats returns how many times each ignore, include, comparator and sorter rule matched
+// in comparisons since Differ is created or reset, in the order they are set, so that unused or
+// overly-greedy rules can be identified in production. Rules never matched are returned with 0 hits.
+// An ignore or include rule matches when it drops or keeps a diff, a comparator or sorter rule matches
+// when it is used on a path. Equal fields recorded by WithReportEqual do not count as matches.
+func (d *Differ) RuleStats() []RuleStat {
+	var stats []RuleStat
+	add := func(kind, rule string, index int) {
+		stats = append(stats, RuleStat{Kind: kind, Rule: rule, Hits: d.ruleHits[ruleKey{kind, index}]})
+	}
+	for i, re := range d.ignores {
+		add("ignore", re.String(), i)
+	}
+	for i, re := range d.includes {
+		add("include", re.String(), i)
+	}
+	for i, c := range d.comparators {
+		add("comparator", ruleName(c), i)
+	}
+	for i, s := range d.sorters {
+		add("sorter", ruleName(s), i)
+	}
+	return stats
+}
+
+// patterned is implemented by comparators and sorters built from a regexp of field paths.
+type patterned interface {
+	pattern() string
+}
+
+// ruleName describes a Comparator or Sorter by its regexp, String() or type, in that order.
+func ruleName(rule interface{}) string {
+	switch r := rule.(type) {
+	case patterned:
+		return r.pattern()
+	case fmt.Stringer:
+		return r.String()
+	}
+	return fmt.Sprintf("%T", rule)
+}
@@ -0,0 +1,91 @@
+package sdiffer
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler runs the full comparison only for a fraction of invocations, or under a rate limit,
+// and returns quickly otherwise, so that comparing production traffic can be afforded.
+// It is safe for concurrent use, the configuration of Differ is copied on each comparison.
+type Sampler struct {
+	// compared and skipped are accessed atomically, keep them 64-bit aligned.
+	compared uint64
+	skipped  uint64
+
+	d    *Differ
+	rate float64
+
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	limit  float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewSampler creates a Sampler comparing with the configuration of d for a fraction rate
+// of invocations, rate is within [0, 1].
+func NewSampler(d *Differ, rate float64) *Sampler {
+	return &Sampler{
+		d:    d,
+		rate: rate,
+		rnd:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:  time.Now,
+	}
+}
+
+// WithRateLimit limits comparisons sampled to perSecond on average with bursts of at most burst.
+func (s *Sampler) WithRateLimit(perSecond float64, burst int) *Sampler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit, s.burst, s.tokens = perSecond, float64(burst), float64(burst)
+	s.last = s.now()
+	return s
+}
+
+// Compare compares a and b if the invocation is sampled, and returns the result and true,
+// or else nil and false.
+func (s *Sampler) Compare(a, b interface{}) (*Result, bool) {
+	if !s.sample() {
+		atomic.AddUint64(&s.skipped, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&s.compared, 1)
+	return s.d.clone().Compare(a, b).Result(), true
+}
+
+// Compared returns the number of comparisons run.
+func (s *Sampler) Compared() uint64 {
+	return atomic.LoadUint64(&s.compared)
+}
+
+// Skipped returns the number of comparisons skipped.
+func (s *Sampler) Skipped() uint64 {
+	return atomic.LoadUint64(&s.skipped)
+}
+
+func (s *Sampler) sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rate < 1 && s.rnd.Float64() >= s.rate {
+		return false
+	}
+	if s.limit <= 0 {
+		return true
+	}
+	now := s.now()
+	s.tokens += now.Sub(s.last).Seconds() * s.limit
+	if s.tokens > s.burst {
+		s.tokens = s.burst
+	}
+	s.last = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
@@ -0,0 +1,108 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// scopedIgnore ignores fields under paths matching fieldRegexp which are deeper than below levels,
+// or whose kind is any of kinds, see IgnoreBelow and IgnoreKinds.
+type scopedIgnore struct {
+	fieldRegexp *regexp.Regexp
+	below       int
+	kinds       []reflect.Kind
+}
+
+// IgnoreBelow ignores fields more than depth levels below paths matching fieldPath, and does not
+// descend into them, 0 ignores all the fields under the paths, which are compared themselves.
+// Diffs of a container itself, such as its length, keys of a map or elements of a slice present
+// on only one side, are counted at the depth of the container.
+// Scoped ignores are kept when Ignore is called, but take no effect when Includes is called.
+//
+// For example:
+// differ := NewDiffer().IgnoreBelow(`\.Blob$`, 2)
+func (d *Differ) IgnoreBelow(fieldPath string, depth int) *Differ {
+	d.scopedIgnores = append(d.scopedIgnores, &scopedIgnore{fieldRegexp: regexp.MustCompile(fieldPath), below: depth})
+	return d
+}
+
+// IgnoreKinds ignores fields of any of kinds under paths matching fieldPath, and does not descend into them.
+// Scoped ignores are kept when Ignore is called, but take no effect when Includes is called.
+//
+// For example:
+// differ := NewDiffer().IgnoreKinds(`^Service\.Cache$`, reflect.Ptr, reflect.Func)
+func (d *Differ) IgnoreKinds(fieldPath string, kinds ...reflect.Kind) *Differ {
+	d.scopedIgnores = append(d.scopedIgnores, &scopedIgnore{fieldRegexp: regexp.MustCompile(fieldPath), below: -1, kinds: kinds})
+	return d
+}
+
+// isScopedIgnored checks if the field of fieldPath of kind is ignored by IgnoreBelow or IgnoreKinds,
+// kind is reflect.Invalid if it is unknown, in which case only IgnoreBelow is checked.
+func (d *Differ) isScopedIgnored(fieldPath string, kind reflect.Kind) bool {
+	if kind == reflect.Invalid {
+		fieldPath = d.ownerPath(fieldPath)
+	}
+	for _, si := range d.scopedIgnores {
+		rel, ok := relativeDepth(fieldPath, si.fieldRegexp)
+		if !ok || rel == 0 {
+			continue
+		}
+		if si.below >= 0 && rel > si.below {
+			return true
+		}
+		for _, k := range si.kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ownerPath returns the path of the container a diff at fieldPath belongs to, such as Blob for
+// Blob[Length], or for a key of map Blob recorded by setEntryDiff, or else fieldPath itself.
+func (d *Differ) ownerPath(fieldPath string) string {
+	if d.entryOwner != "" {
+		return d.entryOwner
+	}
+	for _, suffix := range []string{lengthSuffix, shiftSuffix, indirectionSuffix} {
+		if strings.HasSuffix(fieldPath, suffix) {
+			return strings.TrimSuffix(fieldPath, suffix)
+		}
+	}
+	return fieldPath
+}
+
+// setEntryDiff records a diff of a map key or slice element present on only one side of the
+// container at ownerPath, so that it is counted at the depth of the container by IgnoreBelow.
+func (d *Differ) setEntryDiff(dt DiffType, ownerPath, fieldName string, va, vb interface{}) {
+	d.entryOwner = ownerPath
+	d.setTypedDiff(dt, fieldName, va, vb)
+	d.entryOwner = ""
+}
+
+// relativeDepth returns the number of levels fieldPath is below its shortest ancestor or itself
+// matching re, it returns false if none of them matches.
+// For example, Root.Blob.Items[0].Name is 3 levels below `\.Blob$`.
+func relativeDepth(fieldPath string, re *regexp.Regexp) (int, bool) {
+	var bounds []int
+	for i := 0; i < len(fieldPath); i++ {
+		switch fieldPath[i] {
+		case '.':
+			bounds = append(bounds, i)
+		case '[':
+			bounds = append(bounds, i)
+			if end := closingBracket(fieldPath[i:]); end > 0 {
+				i += end
+			}
+		}
+	}
+	bounds = append(bounds, len(fieldPath))
+	for i, end := range bounds {
+		if re.MatchString(fieldPath[:end]) {
+			return len(bounds) - 1 - i, true
+		}
+	}
+	return 0, false
+}
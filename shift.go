@@ -0,0 +1,69 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+const shiftSuffix = "[Shift]"
+
+// WithShiftDetection makes Differ detect slices whose path matches any of fieldPaths which are identical
+// except for elements inserted at the head. Rather than flagging every index, a ShiftDiff with the number
+// of elements inserted into A and B is recorded under the path "<slice>[Shift]", and inserted elements
+// are recorded as MissingDiff. Elements inserted at the tail are always reported as MissingDiff.
+func (d *Differ) WithShiftDetection(fieldPaths ...string) *Differ {
+	for _, exp := range fieldPaths {
+		d.shifts = append(d.shifts, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// compareShifted checks if slice a or b is the other one with elements inserted at the head,
+// and records the shift and inserted elements if so.
+func (d *Differ) compareShifted(a, b reflect.Value, fieldPath string, depth int) bool {
+	k := a.Len() - b.Len()
+	if k == 0 || !d.isShiftField(fieldPath) {
+		return false
+	}
+	long, short, insertedA := a, b, k > 0
+	if !insertedA {
+		long, short, k = b, a, -k
+	}
+	if short.Len() == 0 || !d.elemsEqual(long, short, k, fieldPath, depth) {
+		return false
+	}
+	if insertedA {
+		d.setTypedDiff(ShiftDiff, fieldPath+shiftSuffix, k, 0)
+	} else {
+		d.setTypedDiff(ShiftDiff, fieldPath+shiftSuffix, 0, k)
+	}
+	for i := 0; i < k && !d.stopped; i++ {
+		elemPath := concat(fieldPath, "[", strconv.Itoa(i), "]")
+		if insertedA {
+			d.setEntryDiff(MissingDiff, fieldPath, elemPath, renderMissing(long.Index(i)), missing)
+		} else {
+			d.setEntryDiff(MissingDiff, fieldPath, elemPath, missing, renderMissing(long.Index(i)))
+		}
+	}
+	return true
+}
+
+// elemsEqual checks if long[i+k] equals to short[i] for every element of short.
+func (d *Differ) elemsEqual(long, short reflect.Value, k int, fieldPath string, depth int) bool {
+	qd := d.clone()
+	qd.quiet = true
+	for i := 0; i < short.Len() && qd.diffCount == 0; i++ {
+		qd.doCompare(long.Index(i+k), short.Index(i), concat(fieldPath, "[", strconv.Itoa(i), "]"), depth)
+	}
+	return qd.diffCount == 0
+}
+
+func (d *Differ) isShiftField(fieldName string) bool {
+	for _, s := range d.shifts {
+		if s.MatchString(fieldName) {
+			return true
+		}
+	}
+	return false
+}
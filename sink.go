@@ -0,0 +1,118 @@
+package sdiffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Sink receives results of completed comparisons, see Differ.WithSink.
+type Sink interface {
+	Write(res *Result) error
+}
+
+// SinkFunc is a function working as Sink.
+type SinkFunc func(res *Result) error
+
+func (fn SinkFunc) Write(res *Result) error {
+	return fn(res)
+}
+
+// KafkaProducer produces a message to a Kafka topic, it can be implemented with any Kafka client,
+// so that this package does not depend on one.
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// resultJSON is the JSON form of Result written by sinks.
+type resultJSON struct {
+	Label string  `json:"label,omitempty"`
+	Diffs []*Diff `json:"diffs"`
+}
+
+func marshalResult(res *Result) ([]byte, error) {
+	dfs := res.Diffs
+	if dfs == nil {
+		dfs = []*Diff{}
+	}
+	return json.Marshal(&resultJSON{Label: res.Label, Diffs: dfs})
+}
+
+// JSONLinesSink writes each result into w as a line of JSON, such as
+// {"label":"...","diffs":[{"path":"...","type":"ElemDiff","a":...,"b":...}]}.
+// It is safe for concurrent use.
+func JSONLinesSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	return SinkFunc(func(res *Result) error {
+		data, err := marshalResult(res)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// WebhookSink POSTs each result to url as JSON with client, http.DefaultClient is used if client is nil.
+// Responses with status codes other than 2xx are returned as errors.
+func WebhookSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return SinkFunc(func(res *Result) error {
+		data, err := marshalResult(res)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s: %s", url, resp.Status)
+		}
+		return nil
+	})
+}
+
+// KafkaSink produces each result to topic as JSON with producer, keyed by the label of result.
+func KafkaSink(producer KafkaProducer, topic string) Sink {
+	return SinkFunc(func(res *Result) error {
+		data, err := marshalResult(res)
+		if err != nil {
+			return err
+		}
+		return producer.Produce(topic, []byte(res.Label), data)
+	})
+}
+
+// WithSink makes Differ write the result into sinks after each comparison completes,
+// the first error returned by sinks is kept, see SinkErr.
+func (d *Differ) WithSink(sinks ...Sink) *Differ {
+	d.sinks = append(d.sinks, sinks...)
+	return d
+}
+
+// SinkErr returns the first error returned by sinks, see WithSink.
+func (d *Differ) SinkErr() error {
+	return d.sinkErr
+}
+
+// writeSinks writes the result into sinks.
+func (d *Differ) writeSinks() {
+	if len(d.sinks) == 0 {
+		return
+	}
+	res := d.Result()
+	for _, s := range d.sinks {
+		if err := s.Write(res); err != nil && d.sinkErr == nil {
+			d.sinkErr = err
+		}
+	}
+}
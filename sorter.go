@@ -1,6 +1,7 @@
 package sdiffer
 
 import (
+	"fmt"
 	"reflect"
 )
 
@@ -23,6 +24,32 @@ type Sorter interface {
 	Less(a, b interface{}) bool
 }
 
+// SorterError is the panic of Sorter.Less, with the path of the slice being sorted
+// and the elements being compared when Less panics.
+type SorterError struct {
+	Path   string
+	A, B   interface{}
+	Reason interface{}
+}
+
+func (e *SorterError) Error() string {
+	return fmt.Sprintf("sorter failed at %s: Less(%+v, %+v) panics: %v", e.Path, e.A, e.B, e.Reason)
+}
+
+// sortBy sorts slice of fieldPath by sorter, it panics with a *SorterError if Less panics.
+func sortBy(slice reflect.Value, sorter Sorter, fieldPath string) {
+	var x, y interface{}
+	defer func() {
+		if r := recover(); r != nil {
+			panic(&SorterError{Path: fieldPath, A: x, B: y, Reason: r})
+		}
+	}()
+	qsort(slice, func(a, b interface{}) bool {
+		x, y = a, b
+		return sorter.Less(a, b)
+	})
+}
+
 func qsort(slice reflect.Value, less func(a, b interface{}) bool) {
 	doQsort(slice, less, 0, slice.Len()-1)
 }
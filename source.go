@@ -0,0 +1,49 @@
+package sdiffer
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithSourceInfo attaches metadata of where a and b come from, such as record IDs, environments
+// and timestamps of fetch, which is carried into diffs found, Result and reports, so that each side
+// can be traced without wrapping the result. It can be set per comparison by OptSourceInfo.
+//
+// For example:
+// differ := NewDiffer().WithSourceInfo(map[string]interface{}{"env": "prod"}, map[string]interface{}{"env": "shadow"})
+func (d *Differ) WithSourceInfo(infoA, infoB map[string]interface{}) *Differ {
+	d.sourceA, d.sourceB = infoA, infoB
+	return d
+}
+
+// SourceA returns the metadata of the source of A, see Differ.WithSourceInfo.
+func (d *Diff) SourceA() map[string]interface{} {
+	return d.sourceA
+}
+
+// SourceB returns the metadata of the source of B, see Differ.WithSourceInfo.
+func (d *Diff) SourceB() map[string]interface{} {
+	return d.sourceB
+}
+
+// renderSourceInfo renders the metadata of both sides in lines like "[A] env=prod id=1", keys are sorted.
+func (d *Differ) renderSourceInfo(bff *bufferF) {
+	for _, side := range []struct {
+		name string
+		info map[string]interface{}
+	}{{"A", d.sourceA}, {"B", d.sourceB}} {
+		if len(side.info) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(side.info))
+		for k := range side.info {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, concat(k, "=", toString(side.info[k])))
+		}
+		bff.sprintf("[%s] %s\n", side.name, strings.Join(pairs, " "))
+	}
+}
@@ -0,0 +1,60 @@
+package sdiffer
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// isSQLNullType checks if t is one of sql.NullString, sql.NullInt64, sql.NullTime and so on.
+func isSQLNullType(t reflect.Type) bool {
+	return t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null")
+}
+
+// compareSQLNull compares sql.Null* values by their validity and value
+// instead of their internal fields.
+func (d *Differ) compareSQLNull(a, b reflect.Value, fieldPath string) {
+	d.visit(fieldPath)
+	va, errA := a.Interface().(driver.Valuer).Value()
+	vb, errB := b.Interface().(driver.Valuer).Value()
+	if errA != nil || errB != nil {
+		d.setDiff(fieldPath, iF(errA != nil, errA, va), iF(errB != nil, errB, vb))
+		return
+	}
+	if va == nil && vb == nil {
+		return
+	}
+	if va == nil || vb == nil {
+		if d.isNullAsZeroField(fieldPath) && isZeroDriverValue(va) && isZeroDriverValue(vb) {
+			return
+		}
+		d.setTypedDiff(NilDiff, fieldPath, iF(va == nil, null, va), iF(vb == nil, null, vb))
+		return
+	}
+	if !reflect.DeepEqual(va, vb) {
+		d.setDiff(fieldPath, va, vb)
+	}
+}
+
+// WithNullAsZero treat NULL and zero value of sql.Null* fields as equal,
+// for example, sql.NullString{} equals sql.NullString{String: "", Valid: true}.
+func (d *Differ) WithNullAsZero(fieldPaths ...string) *Differ {
+	for _, exp := range fieldPaths {
+		d.nullAsZeros = append(d.nullAsZeros, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+func (d *Differ) isNullAsZeroField(fieldPath string) bool {
+	for _, r := range d.nullAsZeros {
+		if r.MatchString(fieldPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func isZeroDriverValue(v driver.Value) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
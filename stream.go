@@ -0,0 +1,74 @@
+package sdiffer
+
+import (
+	"io"
+)
+
+// flusher is implemented by writers such as *bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// httpFlusher is implemented by writers such as http.ResponseWriter.
+type httpFlusher interface {
+	Flush()
+}
+
+// WriteReport makes Differ stream the formatted report into w as diffs are found
+// in the following comparisons, instead of building the whole report in memory.
+//
+// Attention:
+// diffs collapsed by WithAggregation are written only once when they are first found,
+// and the first write error stops streaming, see StreamErr.
+func (d *Differ) WriteReport(w io.Writer) *Differ {
+	d.stream = w
+	return d
+}
+
+// WithFlushPerField makes Differ flush the stream writer of WriteReport whenever
+// diffs of a new top-level field are going to be written, w must implement
+// Flush() error or Flush().
+func (d *Differ) WithFlushPerField() *Differ {
+	d.flushPerField = true
+	return d
+}
+
+// StreamErr returns the first error occurred when streaming report, see WriteReport.
+func (d *Differ) StreamErr() error {
+	return d.streamErr
+}
+
+func (d *Differ) streamLabel() {
+	if !isStringBlank(d.label) {
+		d.streamWrite(concat("[", d.label, "]\n"))
+	}
+}
+
+func (d *Differ) streamDiff(df *Diff) {
+	if d.flushPerField {
+		if field := topLevelField(df.name); field != d.streamField {
+			d.streamFlush()
+			d.streamField = field
+		}
+	}
+	d.streamWrite(d.formatDiff(df, "") + "\n")
+}
+
+func (d *Differ) streamWrite(s string) {
+	if d.stream == nil || d.streamErr != nil {
+		return
+	}
+	_, d.streamErr = io.WriteString(d.stream, s)
+}
+
+func (d *Differ) streamFlush() {
+	if d.stream == nil || d.streamErr != nil {
+		return
+	}
+	switch f := d.stream.(type) {
+	case flusher:
+		d.streamErr = f.Flush()
+	case httpFlusher:
+		f.Flush()
+	}
+}
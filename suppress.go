@@ -0,0 +1,70 @@
+package sdiffer
+
+import (
+	"regexp"
+	"time"
+)
+
+// Suppression is an ignore rule with bookkeeping metadata, so that ignores can be audited.
+type Suppression struct {
+	// Path is the regexp of fields to ignore, just like Differ.Ignore.
+	Path string
+
+	// Owner is who is responsible for the suppression.
+	Owner string
+
+	// Reason is why the fields are ignored.
+	Reason string
+
+	// Expires is when the suppression should be revisited, zero means never.
+	Expires time.Time
+}
+
+// Expired checks if s expires at now.
+func (s Suppression) Expired(now time.Time) bool {
+	return !s.Expires.IsZero() && !now.Before(s.Expires)
+}
+
+type suppression struct {
+	Suppression
+	fieldRegexp *regexp.Regexp
+}
+
+// WithSuppression ignores fields whose path matches s.Path like Ignore, and keeps the metadata of s,
+// see Suppressions and ExpiredSuppressions. Expired suppressions still take effect.
+// Suppressions are kept when Ignore is called, but take no effect when Includes is called.
+func (d *Differ) WithSuppression(ss ...Suppression) *Differ {
+	for _, s := range ss {
+		d.suppressions = append(d.suppressions, &suppression{s, regexp.MustCompile(s.Path)})
+	}
+	return d
+}
+
+// Suppressions returns all suppressions set by WithSuppression.
+func (d *Differ) Suppressions() []Suppression {
+	ss := make([]Suppression, 0, len(d.suppressions))
+	for _, s := range d.suppressions {
+		ss = append(ss, s.Suppression)
+	}
+	return ss
+}
+
+// ExpiredSuppressions returns suppressions set by WithSuppression which expire at now.
+func (d *Differ) ExpiredSuppressions(now time.Time) []Suppression {
+	var ss []Suppression
+	for _, s := range d.suppressions {
+		if s.Expired(now) {
+			ss = append(ss, s.Suppression)
+		}
+	}
+	return ss
+}
+
+func (d *Differ) isSuppressedField(fieldName string) bool {
+	for _, s := range d.suppressions {
+		if s.fieldRegexp.MatchString(fieldName) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// WithSyncMapSnapshot compares sync.Map values as snapshots of their entries taken by Range,
+// entries are compared like maps. It applies to all fields if fieldPaths is empty.
+// Without it, sync.Map values are skipped like other sync primitives, see compareSync.
+//
+// For example:
+// differ := NewDiffer().WithSyncMapSnapshot(`\.Cache$`)
+func (d *Differ) WithSyncMapSnapshot(fieldPaths ...string) *Differ {
+	if len(fieldPaths) == 0 {
+		fieldPaths = []string{".*"}
+	}
+	for _, exp := range fieldPaths {
+		d.syncMaps = append(d.syncMaps, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// compareSync handles types of package sync and sync/atomic, which are either meaningless to compare
+// or not safe to compare by walking their fields. Values of sync/atomic, such as atomic.Value, are compared
+// by Load(), sync.Map is compared by snapshots if WithSyncMapSnapshot is called, and other sync primitives,
+// such as sync.Mutex and sync.WaitGroup, are skipped. It returns false if a and b are of other types.
+func (d *Differ) compareSync(a, b reflect.Value, fieldPath string, depth int) (handled bool) {
+	if a.Kind() != reflect.Struct {
+		return false
+	}
+	switch a.Type().PkgPath() {
+	case "sync/atomic":
+		la, okA := loadAtomic(a)
+		lb, okB := loadAtomic(b)
+		if !okA || !okB {
+			return false
+		}
+		switch {
+		case !la.IsValid() && !lb.IsValid():
+			d.visit(fieldPath)
+		case !la.IsValid() || !lb.IsValid():
+			d.setTypedDiff(NilDiff, fieldPath, iF(la.IsValid(), notNull, null), iF(lb.IsValid(), notNull, null))
+		case la.Type() != lb.Type():
+			d.setDiff(fieldPath, renderMissing(la), renderMissing(lb))
+		default:
+			d.doCompare(la, lb, fieldPath, depth)
+		}
+		return true
+	case "sync":
+		if a.Type() == syncMapType && d.isSyncMapSnapshot(fieldPath) {
+			sa, sb := snapshotSyncMap(a), snapshotSyncMap(b)
+			if sa.IsValid() && sb.IsValid() {
+				d.doCompare(sa, sb, fieldPath, depth)
+				return true
+			}
+		}
+		d.visit(fieldPath)
+		return true
+	}
+	return false
+}
+
+func (d *Differ) isSyncMapSnapshot(fieldPath string) bool {
+	for _, re := range d.syncMaps {
+		if re.MatchString(fieldPath) {
+			return true
+		}
+	}
+	return false
+}
+
+var syncMapType = reflect.TypeOf((*sync.Map)(nil)).Elem()
+
+// loadAtomic returns the result of Load() of atomic value v, or the dynamic value of it if it is an interface,
+// which is invalid if nothing is stored. If Load can not be called on v, such as an unexported field,
+// the field v holding the value is read instead. It returns false if v has neither of them.
+func loadAtomic(v reflect.Value) (loaded reflect.Value, ok bool) {
+	if ptr := addressable(v); ptr.IsValid() {
+		if load := ptr.MethodByName("Load"); load.IsValid() && load.Type().NumIn() == 0 && load.Type().NumOut() == 1 {
+			loaded, ok = load.Call(nil)[0], true
+		}
+	}
+	if !ok {
+		loaded = v.FieldByName("v")
+		ok = loaded.IsValid()
+	}
+	if ok && loaded.Kind() == reflect.Interface {
+		loaded = loaded.Elem()
+	}
+	return
+}
+
+// snapshotSyncMap returns the entries of sync.Map v as a map[interface{}]interface{},
+// an invalid reflect.Value is returned if Range can not be called on v, such as an unexported field.
+func snapshotSyncMap(v reflect.Value) reflect.Value {
+	ptr := addressable(v)
+	if !ptr.IsValid() {
+		return reflect.Value{}
+	}
+	snapshot := make(map[interface{}]interface{})
+	ptr.Interface().(*sync.Map).Range(func(k, v interface{}) bool {
+		snapshot[k] = v
+		return true
+	})
+	return reflect.ValueOf(snapshot)
+}
+
+// addressable returns a pointer to v, or to a copy of v if v is not addressable,
+// an invalid reflect.Value is returned if v can not be interfaced.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.CanInterface() {
+		return reflect.Value{}
+	}
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr
+}
@@ -0,0 +1,71 @@
+package sdiffer
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// WithTextual compare values implementing encoding.TextMarshaler or fmt.Stringer by their textual
+// representation, such as net.IP, url.URL and uuid.UUID, which simplifies reports and avoids diffs
+// in internal representation. It applies to all fields if fieldPaths is empty.
+func (d *Differ) WithTextual(fieldPaths ...string) *Differ {
+	if len(fieldPaths) == 0 {
+		fieldPaths = []string{".*"}
+	}
+	for _, exp := range fieldPaths {
+		d.textuals = append(d.textuals, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// compareText compares a and b by text, it returns false if they are not textual.
+func (d *Differ) compareText(a, b reflect.Value, fieldPath string) (handled bool) {
+	if len(d.textuals) == 0 || !d.isTextualField(fieldPath) {
+		return false
+	}
+	ta, okA := textOf(a)
+	tb, okB := textOf(b)
+	if !okA || !okB {
+		return false
+	}
+	d.visit(fieldPath)
+	if ta != tb {
+		d.setDiff(fieldPath, ta, tb)
+	}
+	return true
+}
+
+func (d *Differ) isTextualField(fieldPath string) bool {
+	for _, r := range d.textuals {
+		if r.MatchString(fieldPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// textOf returns the text of v if it implements encoding.TextMarshaler or fmt.Stringer.
+func textOf(v reflect.Value) (string, bool) {
+	if !v.CanInterface() || ((v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()) {
+		return "", false
+	}
+	candidates := []interface{}{v.Interface()}
+	if v.CanAddr() {
+		candidates = append(candidates, v.Addr().Interface())
+	}
+	for _, c := range candidates {
+		if tm, ok := c.(encoding.TextMarshaler); ok {
+			if text, err := tm.MarshalText(); err == nil {
+				return string(text), true
+			}
+		}
+	}
+	for _, c := range candidates {
+		if s, ok := c.(fmt.Stringer); ok {
+			return s.String(), true
+		}
+	}
+	return "", false
+}
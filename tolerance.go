@@ -0,0 +1,114 @@
+package sdiffer
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tolerance struct {
+	fieldRegexp *regexp.Regexp
+	abs         float64
+	rel         float64
+
+	// duration limits the tolerance to time.Duration values and duration strings, see WithDurationTolerance.
+	duration bool
+}
+
+// appliesTo checks if t applies to values of type typ, a nil typ stands for numbers parsed from strings.
+func (t *tolerance) appliesTo(typ reflect.Type) bool {
+	return !t.duration || typ == durationType
+}
+
+// equals checks if fa and fb are equal within the absolute or relative tolerance.
+func (t *tolerance) equals(fa, fb float64) bool {
+	delta := math.Abs(fa - fb)
+	if delta <= t.abs {
+		return true
+	}
+	return t.rel > 0 && delta <= t.rel*math.Max(math.Abs(fa), math.Abs(fb))
+}
+
+// WithTolerance treat numbers as equal if the absolute difference of them is not greater than epsilon,
+// it applies to fields of int, uint and float kinds, and strings which can be parsed as numbers.
+func (d *Differ) WithTolerance(fieldPath string, epsilon float64) *Differ {
+	d.tolerances = append(d.tolerances, &tolerance{fieldRegexp: regexp.MustCompile(fieldPath), abs: epsilon})
+	return d
+}
+
+// WithRelativeTolerance treat numbers as equal if the absolute difference of them is not greater than
+// ratio times the larger absolute value of them, e.g. 0.01 means 1%.
+// It suits numbers spanning orders of magnitude where an absolute epsilon does not work.
+func (d *Differ) WithRelativeTolerance(fieldPath string, ratio float64) *Differ {
+	d.tolerances = append(d.tolerances, &tolerance{fieldRegexp: regexp.MustCompile(fieldPath), rel: ratio})
+	return d
+}
+
+// withinTolerance checks if a and b are numbers equal within the tolerance of fieldPath.
+func (d *Differ) withinTolerance(a, b reflect.Value, fieldPath string) bool {
+	for _, t := range d.tolerances {
+		if !t.fieldRegexp.MatchString(fieldPath) || !t.appliesTo(a.Type()) {
+			continue
+		}
+		fa, okA := toFloat(a)
+		fb, okB := toFloat(b)
+		return okA && okB && t.equals(fa, fb)
+	}
+	return false
+}
+
+// withinFloatTolerance checks if fa and fb are equal, or equal within the tolerance of fieldPath,
+// typ is durationType if they are parsed from duration strings.
+func (d *Differ) withinFloatTolerance(fa, fb float64, fieldPath string, typ reflect.Type) bool {
+	if fa == fb {
+		return true
+	}
+	for _, t := range d.tolerances {
+		if t.fieldRegexp.MatchString(fieldPath) && t.appliesTo(typ) {
+			return t.equals(fa, fb)
+		}
+	}
+	return false
+}
+
+type lengthTolerance struct {
+	fieldRegexp *regexp.Regexp
+	n           int
+}
+
+// WithLengthTolerance treat lengths of slices or maps as equal if they differ by at most n,
+// elements in both of them are still compared while extra elements of slices are not reported.
+// Keys present in only one of the maps are always reported, only the LengthDiff of maps is suppressed.
+func (d *Differ) WithLengthTolerance(fieldPath string, n int) *Differ {
+	d.lenTolerances = append(d.lenTolerances, &lengthTolerance{regexp.MustCompile(fieldPath), n})
+	return d
+}
+
+// withinLengthTolerance checks if lengths of a and b are equal within the length tolerance of fieldPath.
+func (d *Differ) withinLengthTolerance(a, b reflect.Value, fieldPath string) bool {
+	for _, t := range d.lenTolerances {
+		if t.fieldRegexp.MatchString(fieldPath) {
+			diff := a.Len() - b.Len()
+			return diff <= t.n && -diff <= t.n
+		}
+	}
+	return false
+}
+
+// toFloat converts numbers or numeric strings into float64.
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
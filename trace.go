@@ -0,0 +1,78 @@
+package sdiffer
+
+import (
+	"regexp"
+	"time"
+)
+
+// Tracer starts spans of comparisons, it is a small subset of tracing APIs
+// so that OpenTelemetry or other tracing libraries can be adapted without being depended on.
+//
+// For example, with OpenTelemetry:
+//
+//	func (t *otelTracer) StartSpan(name string, parent sdiffer.Span) sdiffer.Span {
+//		ctx := t.ctx
+//		if p, ok := parent.(*otelSpan); ok {
+//			ctx = p.ctx
+//		}
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return &otelSpan{ctx: ctx, span: span}
+//	}
+type Tracer interface {
+	// StartSpan starts a span named name, parent is nil for the span of a Compare.
+	StartSpan(name string, parent Span) Span
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+const (
+	traceSpanName    = "sdiffer.Compare"
+	traceAttrPath    = "sdiffer.path"
+	traceAttrNodes   = "sdiffer.nodes"
+	traceAttrDiffs   = "sdiffer.diffs"
+	traceAttrElapsed = "sdiffer.duration_ms"
+)
+
+// WithTracer makes each Compare create a span with attributes of the root path, the number of nodes
+// compared, the number of diffs found and the duration in milliseconds. Subtrees whose path matches
+// any of childPaths emit child spans with the same attributes, so that the cost of large subtrees can be seen.
+func (d *Differ) WithTracer(tracer Tracer, childPaths ...string) *Differ {
+	d.tracer = tracer
+	for _, exp := range childPaths {
+		d.tracePaths = append(d.tracePaths, regexp.MustCompile(exp))
+	}
+	return d
+}
+
+// trace starts a span of fieldPath, and returns the function ending it.
+func (d *Differ) trace(fieldPath string) func() {
+	span := d.tracer.StartSpan(traceSpanName, d.span)
+	parent := d.span
+	d.span = span
+	start, nodes, diffs := time.Now(), d.nodeCount, d.diffCount
+	return func() {
+		span.SetAttribute(traceAttrPath, fieldPath)
+		span.SetAttribute(traceAttrNodes, d.nodeCount-nodes)
+		span.SetAttribute(traceAttrDiffs, d.diffCount-diffs)
+		span.SetAttribute(traceAttrElapsed, float64(time.Since(start))/float64(time.Millisecond))
+		span.End()
+		d.span = parent
+	}
+}
+
+// isTracedPath checks if the subtree of fieldPath should emit a child span.
+func (d *Differ) isTracedPath(fieldPath string) bool {
+	if d.tracer == nil || d.span == nil {
+		return false
+	}
+	for _, re := range d.tracePaths {
+		if re.MatchString(fieldPath) {
+			return true
+		}
+	}
+	return false
+}
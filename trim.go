@@ -1,6 +1,7 @@
 package sdiffer
 
 import (
+	"reflect"
 	"regexp"
 	"strings"
 )
@@ -21,3 +22,73 @@ func (tt *trimTag) Trim(s string) string {
 	res := strings.Trim(s, tt.cutset)
 	return res
 }
+
+// trimText trims s by the first rule set by WithTrimSpace or WithTrim matching fieldPath,
+// it returns false if no rule matches.
+func (d *Differ) trimText(s, fieldPath string) (string, bool) {
+	for _, ts := range d.trimSpaces {
+		if ts.MatchString(fieldPath) {
+			return strings.TrimSpace(s), true
+		}
+	}
+	for _, tt := range d.trimTags {
+		if tt.fieldRegexp.MatchString(fieldPath) {
+			return tt.Trim(s), true
+		}
+	}
+	return s, false
+}
+
+// compareTrimmed compares []byte values, or leaf values implementing fmt.Stringer or encoding.TextMarshaler,
+// by their texts trimmed by the rule matching fieldPath, it returns false if they are not compared.
+// Strings are trimmed when they are compared by kind, and structs, pointers and containers are always
+// walked, so that their fields are never hidden behind their texts.
+func (d *Differ) compareTrimmed(a, b reflect.Value, fieldPath string) (handled bool) {
+	if len(d.trimSpaces)+len(d.trimTags) == 0 || a.Kind() == reflect.String || a.Kind() == reflect.Interface {
+		return false
+	}
+	ta, okA := trimmableText(a)
+	tb, okB := trimmableText(b)
+	if !okA || !okB {
+		return false
+	}
+	sa, ok := d.trimText(ta, fieldPath)
+	if !ok {
+		return false
+	}
+	sb, _ := d.trimText(tb, fieldPath)
+	d.visit(fieldPath)
+	d.checkEqual(sa == sb, fieldPath, a, b)
+	return true
+}
+
+// trimmableText returns the text of v if it is a []byte, or a textual value without fields or elements
+// to walk, see textOf.
+func trimmableText(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true
+		}
+		return "", false
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Ptr, reflect.Interface:
+		return "", false
+	}
+	return textOf(v)
+}
+
+// trimKeyMatcher matches string keys of maps of fieldPath if they are equal after trimmed by the rule
+// matching fieldPath, it returns nil if keys are not strings or no rule matches.
+func (d *Differ) trimKeyMatcher(keyType reflect.Type, fieldPath string) KeyMatcher {
+	if keyType.Kind() != reflect.String {
+		return nil
+	}
+	if _, ok := d.trimText("", fieldPath); !ok {
+		return nil
+	}
+	return func(ka, kb interface{}) bool {
+		sa, _ := d.trimText(reflect.ValueOf(ka).String(), fieldPath)
+		sb, _ := d.trimText(reflect.ValueOf(kb).String(), fieldPath)
+		return sa == sb
+	}
+}
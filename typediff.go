@@ -0,0 +1,131 @@
+package sdiffer
+
+import "reflect"
+
+// TypeDiffer compares two types instead of values, such as two versions of an API model,
+// and reports added, removed and renamed fields, type changes and tag changes.
+//
+// Diffs are recorded into a Differ, for example:
+// Model.Name       NilDiff, the field is added or removed
+// Model.Id[Name]   the field is renamed, A and B are the old and new names
+// Model.Age[Type]  the type of the field is changed
+// Model.Age[Tag]   the tag of the field is changed
+type TypeDiffer struct {
+	maxDepth int
+	visited  map[[2]reflect.Type]struct{}
+}
+
+func NewTypeDiffer() *TypeDiffer {
+	return &TypeDiffer{maxDepth: defaultDepthLimit}
+}
+
+// Compare compares the types of a and b, a and b can be reflect.Type or values of the types.
+// A nil on only one side is recorded as a NilDiff at the root.
+func (td *TypeDiffer) Compare(a, b interface{}) *Differ {
+	ta, tb := typeOf(a), typeOf(b)
+	d := NewDiffer()
+	if ta == nil || tb == nil {
+		if ta != nil {
+			d.setTypedDiff(NilDiff, rootTypeName(ta), ta.String(), null)
+		} else if tb != nil {
+			d.setTypedDiff(NilDiff, rootTypeName(tb), null, tb.String())
+		}
+		return d
+	}
+	td.visited = make(map[[2]reflect.Type]struct{})
+	name := iF(ta.Name() == "", initTypeName, ta.Name()).(string)
+	if ta.Kind() == reflect.Ptr && ta.Elem().Name() != "" {
+		name = ta.Elem().Name()
+	}
+	td.compare(d, ta, tb, name, 0)
+	return d
+}
+
+// typeOf returns v if it is a reflect.Type, or else the type of v, which is nil if v is nil.
+func typeOf(v interface{}) reflect.Type {
+	if t, ok := v.(reflect.Type); ok {
+		return t
+	}
+	return reflect.TypeOf(v)
+}
+
+func (td *TypeDiffer) compare(d *Differ, a, b reflect.Type, fieldPath string, depth int) {
+	if depth > td.maxDepth || a == b {
+		return
+	}
+	// visited holds the pairs on the current recursion stack only, so that recursive types terminate
+	// while the same pair of types in sibling fields is still compared.
+	pair := [2]reflect.Type{a, b}
+	if _, ok := td.visited[pair]; ok {
+		return
+	}
+	td.visited[pair] = struct{}{}
+	defer delete(td.visited, pair)
+	if a.Kind() != b.Kind() {
+		d.setDiff(fieldPath+"[Type]", a.String(), b.String())
+		return
+	}
+	switch a.Kind() {
+	case reflect.Array:
+		if a.Len() != b.Len() {
+			d.setDiff(fieldPath+"[Type]", a.String(), b.String())
+			return
+		}
+		td.compare(d, a.Elem(), b.Elem(), fieldPath, depth)
+	case reflect.Ptr, reflect.Slice:
+		td.compare(d, a.Elem(), b.Elem(), fieldPath, depth)
+	case reflect.Map:
+		td.compare(d, a.Key(), b.Key(), fieldPath+"[Key]", depth)
+		td.compare(d, a.Elem(), b.Elem(), fieldPath, depth)
+	case reflect.Struct:
+		td.compareFields(d, a, b, fieldPath, depth)
+	default:
+		if a.String() != b.String() {
+			d.setDiff(fieldPath+"[Type]", a.String(), b.String())
+		}
+	}
+}
+
+func (td *TypeDiffer) compareFields(d *Differ, a, b reflect.Type, fieldPath string, depth int) {
+	var removed, added []reflect.StructField
+	for i := 0; i < a.NumField(); i++ {
+		fa := a.Field(i)
+		if fb, ok := b.FieldByName(fa.Name); ok && len(fb.Index) == 1 {
+			td.compareField(d, fa, fb, concat(fieldPath, ".", fa.Name), depth)
+		} else {
+			removed = append(removed, fa)
+		}
+	}
+	for i := 0; i < b.NumField(); i++ {
+		fb := b.Field(i)
+		if fa, ok := a.FieldByName(fb.Name); !ok || len(fa.Index) != 1 {
+			added = append(added, fb)
+		}
+	}
+	// a removed field and an added field with the same type and tag are treated as renamed.
+	for _, fa := range removed {
+		path := concat(fieldPath, ".", fa.Name)
+		renamed := false
+		for i, fb := range added {
+			if fa.Type == fb.Type && fa.Tag == fb.Tag {
+				d.setDiff(path+"[Name]", fa.Name, fb.Name)
+				added = append(added[:i], added[i+1:]...)
+				renamed = true
+				break
+			}
+		}
+		if !renamed {
+			d.setTypedDiff(NilDiff, path, fa.Type.String(), null)
+		}
+	}
+	for _, fb := range added {
+		d.setTypedDiff(NilDiff, concat(fieldPath, ".", fb.Name), null, fb.Type.String())
+	}
+}
+
+func (td *TypeDiffer) compareField(d *Differ, a, b reflect.StructField, fieldPath string, depth int) {
+	if a.Tag != b.Tag {
+		d.setDiff(fieldPath+"[Tag]", string(a.Tag), string(b.Tag))
+	}
+	td.compare(d, a.Type, b.Type, fieldPath, depth+1)
+}
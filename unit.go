@@ -0,0 +1,50 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// UnitNormalizer normalizes a quantity string such as "5s" or "1Gi" into a number in its base unit,
+// it returns false if s is not a quantity it knows.
+type UnitNormalizer func(s string) (float64, bool)
+
+// WithUnitNormalizer adds a UnitNormalizer used by fields set by WithUnitAware,
+// two strings are treated as equal if they are normalized into the same number by fn,
+// or numbers within the tolerance set by WithTolerance or WithRelativeTolerance.
+//
+// For example:
+// differ := NewDiffer().WithUnitAware(`Percent`).WithUnitNormalizer(parsePercent)
+func (d *Differ) WithUnitNormalizer(fn UnitNormalizer) *Differ {
+	d.normalizers = append(d.normalizers, fn)
+	return d
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// WithDurationTolerance treat time.Duration fields, and duration strings of fields set by WithUnitAware,
+// as equal if the absolute difference of them is not greater than tol. Other numbers matching fieldPath
+// are not affected.
+func (d *Differ) WithDurationTolerance(fieldPath string, tol time.Duration) *Differ {
+	d.tolerances = append(d.tolerances, &tolerance{fieldRegexp: regexp.MustCompile(fieldPath), abs: float64(tol), duration: true})
+	return d
+}
+
+// equalQuantity checks if quantity strings a and b of fieldPath are equal in value.
+func (d *Differ) equalQuantity(a, b string, fieldPath string) bool {
+	if equalQuantity(a, b) {
+		return true
+	}
+	for _, normalize := range d.normalizers {
+		na, okA := normalize(a)
+		nb, okB := normalize(b)
+		if okA && okB {
+			return d.withinFloatTolerance(na, nb, fieldPath, nil)
+		}
+	}
+	da, errA := time.ParseDuration(strings.TrimSpace(a))
+	db, errB := time.ParseDuration(strings.TrimSpace(b))
+	return errA == nil && errB == nil && d.withinFloatTolerance(float64(da), float64(db), fieldPath, durationType)
+}
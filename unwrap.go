@@ -0,0 +1,77 @@
+package sdiffer
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Unwrapper returns the value contained by v, see Differ.WithUnwrapByName.
+type Unwrapper func(v reflect.Value) reflect.Value
+
+// WithUnwrap compare the value contained by a container type directly under the parent path,
+// instead of the internals of the container, fn must be like func(c T) U, or else WithUnwrap will panic.
+//
+// For example:
+// differ.WithUnwrap(func(o Optional[int]) int { return o.val })
+func (d *Differ) WithUnwrap(fn interface{}) *Differ {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.NumOut() != 1 {
+		panic("unwrap function must be like func(c T) U, got: " + ft.String())
+	}
+	if d.unwraps == nil {
+		d.unwraps = make(map[reflect.Type]Unwrapper)
+	}
+	d.unwraps[ft.In(0)] = func(v reflect.Value) reflect.Value {
+		return fv.Call([]reflect.Value{v})[0]
+	}
+	return d
+}
+
+// WithUnwrapByName works like WithUnwrap, but fn applies to all types whose name without
+// type arguments equals to name, so that one function serves all instantiations of a generic type.
+//
+// For example:
+// differ.WithUnwrapByName("Optional", func(v reflect.Value) reflect.Value { return v.FieldByName("Value") })
+func (d *Differ) WithUnwrapByName(name string, fn Unwrapper) *Differ {
+	if d.namedUnwraps == nil {
+		d.namedUnwraps = make(map[string]Unwrapper)
+	}
+	d.namedUnwraps[name] = fn
+	return d
+}
+
+// findUnwrapper finds the Unwrapper of t, nil will be returned if it is not found.
+func (d *Differ) findUnwrapper(t reflect.Type) Unwrapper {
+	if fn, ok := d.unwraps[t]; ok {
+		return fn
+	}
+	if len(d.namedUnwraps) == 0 {
+		return nil
+	}
+	name := t.Name()
+	if idx := strings.Index(name, "["); idx > 0 {
+		name = name[:idx]
+	}
+	return d.namedUnwraps[name]
+}
+
+// unwrap unwraps a and b, it returns false if there is no Unwrapper for them.
+func (d *Differ) unwrap(a, b reflect.Value, fieldPath string, depth int) (handled bool) {
+	fn := d.findUnwrapper(a.Type())
+	if fn == nil || !a.CanInterface() {
+		return false
+	}
+	ua, ub := fn(a), fn(b)
+	if ua.Kind() == reflect.Interface {
+		if ua.IsNil() || ub.IsNil() {
+			if ua.IsNil() != ub.IsNil() {
+				d.setNilDiff(fieldPath, ua, ub)
+			}
+			return true
+		}
+		ua, ub = ua.Elem(), ub.Elem()
+	}
+	d.doCompare(ua, ub, fieldPath, depth)
+	return true
+}
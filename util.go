@@ -3,6 +3,8 @@ package sdiffer
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -47,6 +49,113 @@ func toString(i interface{}) string {
 	return fmt.Sprintf("%v", i)
 }
 
+// matchIndex returns the index of the first of res matching s, or -1 if none matches.
+func matchIndex(res []*regexp.Regexp, s string) int {
+	for i, re := range res {
+		if re.MatchString(s) {
+			return i
+		}
+	}
+	return -1
+}
+
+func compileRegexps(exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// countPlaceholders counts verbs in a fmt format string, "%%" is not counted.
+func countPlaceholders(format string) (n int) {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			i++
+			continue
+		}
+		n++
+	}
+	return
+}
+
+// formatKey renders map key in a canonical form, pointers are dereferenced
+// and struct fields are sorted by name, such as:
+// &Key{B: 1, A: "x"} => {A:x,B:1}
+func formatKey(k reflect.Value) string {
+	for i := 0; i < defaultDepthLimit && (k.Kind() == reflect.Ptr || k.Kind() == reflect.Interface); i++ {
+		if k.IsNil() {
+			return null
+		}
+		k = k.Elem()
+	}
+	if k.Kind() != reflect.Struct {
+		if !k.CanInterface() {
+			return toString(k)
+		}
+		return toString(k.Interface())
+	}
+	names := make([]string, 0, k.NumField())
+	fields := make(map[string]string, k.NumField())
+	for i, n := 0, k.NumField(); i < n; i++ {
+		name := k.Type().Field(i).Name
+		names = append(names, name)
+		fields[name] = formatKey(k.Field(i))
+	}
+	sort.Strings(names)
+	builder := &strings.Builder{}
+	builder.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(concat(name, ":", fields[name]))
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// interfaceOf unwraps v if it is a reflect.Value which can be interfaced.
+func interfaceOf(v interface{}) interface{} {
+	if rv, ok := v.(reflect.Value); ok && rv.IsValid() && rv.CanInterface() {
+		return rv.Interface()
+	}
+	return v
+}
+
+// nonNilZero returns a non-nil zero value of pointer, slice or map type t,
+// such as a pointer to the zero value of elem, an empty slice or map.
+func nonNilZero(t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return reflect.New(t.Elem())
+	case reflect.Slice:
+		return reflect.MakeSlice(t, 0, 0)
+	case reflect.Map:
+		return reflect.MakeMap(t)
+	}
+	return reflect.Zero(t)
+}
+
+// isCompositeType checks if t, or the type t points to, has fields or elements.
+func isCompositeType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -54,6 +163,13 @@ func minInt(a, b int) int {
 	return b
 }
 
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func copySliceValue(sv reflect.Value) reflect.Value {
 	length := sv.Len()
 	copiedSv := reflect.MakeSlice(sv.Type(), length, length)
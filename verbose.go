@@ -0,0 +1,45 @@
+package sdiffer
+
+const equalSuffix = " (equal)"
+
+// WithReportEqual makes Differ record fields found equal too, they are marked as NoDiff,
+// and rendered with the suffix " (equal)" in the report along with diffs, so that the report shows
+// full side-by-side context rather than only the differences. HasDiffs and Diffs are not affected.
+func (d *Differ) WithReportEqual() *Differ {
+	d.reportEqual = true
+	return d
+}
+
+// EqualFields returns fields found equal sorted by path, WithReportEqual must be called
+// before Compare, or else nil will be returned.
+func (d *Differ) EqualFields() []*Diff {
+	if d.equals == nil {
+		return nil
+	}
+	dfs := make([]*Diff, 0, len(d.equals))
+	for _, df := range d.equals {
+		dfs = append(dfs, df)
+	}
+	sortDiffs(dfs)
+	return dfs
+}
+
+func (d *Differ) setEqual(fieldName string, va, vb interface{}) {
+	if !d.reportEqual || d.quiet {
+		return
+	}
+	// equal fields do not count hits of rules, so that RuleStats does not depend on WithReportEqual.
+	if !d.reportsField(fieldName) {
+		return
+	}
+	if d.isRedactedField(fieldName) {
+		va, vb = redacted, redacted
+	}
+	if d.equals == nil {
+		d.equals = make(map[string]*Diff, 16)
+	}
+	df := newDiff(d.stylePath(fieldName), interfaceOf(va), interfaceOf(vb))
+	df.dt = NoDiff
+	df.compareID = d.compareID
+	d.equals[fieldName] = df
+}
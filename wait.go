@@ -0,0 +1,55 @@
+package sdiffer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WaitUntilEqual loads values by getA and getB and compares them on every interval,
+// until they are equal under the rules set by opts, or ctx is done.
+// A *NotEqualError with the diff report of the last comparison is returned if ctx is done first.
+func WaitUntilEqual(ctx context.Context, getA, getB Loader, interval time.Duration, opts ...Option) error {
+	d := NewDiffer()
+	for _, opt := range opts {
+		opt(d)
+	}
+	var last *NotEqualError
+	for {
+		last = compareLoaded(d.clone(), getA, getB)
+		if last == nil {
+			return nil
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			if last.Cause == nil {
+				last.Cause = ctx.Err()
+			} else {
+				last.Cause = fmt.Errorf("%v: %w", ctx.Err(), last.Cause)
+			}
+			return last
+		}
+	}
+}
+
+// compareLoaded compares values loaded, it returns nil if they are equal.
+func compareLoaded(d *Differ, getA, getB Loader) (nee *NotEqualError) {
+	a, err := getA()
+	if err != nil {
+		return &NotEqualError{Cause: fmt.Errorf("load a: %w", err)}
+	}
+	b, err := getB()
+	if err != nil {
+		return &NotEqualError{Cause: fmt.Errorf("load b: %w", err)}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			nee = &NotEqualError{Cause: fmt.Errorf("compare: %v", p)}
+		}
+	}()
+	if d.Compare(a, b).HasDiffs() {
+		return &NotEqualError{Result: d.Result(), Report: d.String()}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sdiffer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Loader loads a value to compare, see Watcher.
+type Loader func() (interface{}, error)
+
+// WatchEvent is a change of diffs found by Watcher.
+type WatchEvent struct {
+	At time.Time
+
+	// Result is the result of the latest comparison.
+	Result *Result
+
+	// Appeared and Resolved are diffs changed since the previous comparison, see CompareResults.
+	Appeared []*Diff
+	Resolved []*Diff
+
+	// Err is the error occurred when loading or comparing values, Result is nil in that case.
+	Err error
+}
+
+// Watcher loads two values by loaders on an interval and compares them,
+// and pushes an event whenever diffs appear or are resolved.
+//
+// For example:
+// w := NewWatcher(differ, loadA, loadB, time.Second)
+// go w.Run(ctx)
+// for e := range w.Events() { ... }
+type Watcher struct {
+	differ   *Differ
+	loadA    Loader
+	loadB    Loader
+	interval time.Duration
+	events   chan *WatchEvent
+}
+
+func NewWatcher(d *Differ, loadA, loadB Loader, interval time.Duration) *Watcher {
+	return &Watcher{
+		differ:   d,
+		loadA:    loadA,
+		loadB:    loadB,
+		interval: interval,
+		events:   make(chan *WatchEvent, 1),
+	}
+}
+
+// Events returns the channel of events, it is closed when Run returns.
+func (w *Watcher) Events() <-chan *WatchEvent {
+	return w.events
+}
+
+// Run compares values immediately and then on every interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.events)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	var prev []*Diff
+	for {
+		r, err := w.compareOnce()
+		e := &WatchEvent{At: time.Now(), Result: r, Err: err}
+		if err == nil {
+			e.Appeared, e.Resolved = CompareResults(prev, r.Diffs)
+			prev = r.Diffs
+		}
+		if err != nil || len(e.Appeared) > 0 || len(e.Resolved) > 0 {
+			select {
+			case w.events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Watcher) compareOnce() (r *Result, err error) {
+	a, err := w.loadA()
+	if err != nil {
+		return nil, fmt.Errorf("load a: %w", err)
+	}
+	b, err := w.loadB()
+	if err != nil {
+		return nil, fmt.Errorf("load b: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			r, err = nil, fmt.Errorf("compare: %v", p)
+		}
+	}()
+	return w.differ.clone().Compare(a, b).Result(), nil
+}
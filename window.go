@@ -0,0 +1,95 @@
+package sdiffer
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	unmatchedASuffix = "[UnmatchedA]"
+	unmatchedBSuffix = "[UnmatchedB]"
+)
+
+type timeWindow struct {
+	fieldRegexp *regexp.Regexp
+	window      time.Duration
+	timeOf      func(elem interface{}) time.Time
+}
+
+// WithTimeWindowMatch pairs elements of slices whose path matches fieldPath by their timestamps instead of indices,
+// elements of A and B are paired in time order if their timestamps returned by timeOf differ by at most window,
+// and paired elements are compared under the index of A. Elements not paired are reported separately
+// as MissingDiff under "[UnmatchedA][i]" and "[UnmatchedB][j]", where i and j are their indices in A and B.
+//
+// For example:
+//
+//	differ := NewDiffer().WithTimeWindowMatch(`\.Events$`, time.Second, func(e interface{}) time.Time {
+//		return e.(*Event).At
+//	})
+func (d *Differ) WithTimeWindowMatch(fieldPath string, window time.Duration, timeOf func(elem interface{}) time.Time) *Differ {
+	d.timeWindows = append(d.timeWindows, &timeWindow{regexp.MustCompile(fieldPath), window, timeOf})
+	return d
+}
+
+// compareTimeWindowed compares slices a and b by pairing elements within the time window of fieldPath,
+// it returns false if no time window is set for fieldPath.
+func (d *Differ) compareTimeWindowed(a, b reflect.Value, fieldPath string, depth int) bool {
+	var tw *timeWindow
+	for _, t := range d.timeWindows {
+		if t.fieldRegexp.MatchString(fieldPath) {
+			tw = t
+			break
+		}
+	}
+	if tw == nil || !a.CanInterface() {
+		return false
+	}
+	ia, ta := tw.order(a)
+	ib, tb := tw.order(b)
+	i, j := 0, 0
+	for (i < len(ia) || j < len(ib)) && !d.stopped {
+		switch {
+		case i < len(ia) && j < len(ib) && absDuration(ta[i].Sub(tb[j])) <= tw.window:
+			d.doCompare(a.Index(ia[i]), b.Index(ib[j]), concat(fieldPath, "[", strconv.Itoa(ia[i]), "]"), depth)
+			i++
+			j++
+		case j >= len(ib) || i < len(ia) && ta[i].Before(tb[j]):
+			d.setTypedDiff(MissingDiff, concat(fieldPath, unmatchedASuffix, "[", strconv.Itoa(ia[i]), "]"),
+				renderMissing(a.Index(ia[i])), missing)
+			i++
+		default:
+			d.setTypedDiff(MissingDiff, concat(fieldPath, unmatchedBSuffix, "[", strconv.Itoa(ib[j]), "]"),
+				missing, renderMissing(b.Index(ib[j])))
+			j++
+		}
+	}
+	return true
+}
+
+// order returns indices of elements of slice v sorted by their timestamps, and the timestamps.
+func (tw *timeWindow) order(v reflect.Value) ([]int, []time.Time) {
+	indices := make([]int, v.Len())
+	times := make([]time.Time, v.Len())
+	for i := range indices {
+		indices[i] = i
+		times[i] = tw.timeOf(v.Index(i).Interface())
+	}
+	sort.SliceStable(indices, func(x, y int) bool {
+		return times[indices[x]].Before(times[indices[y]])
+	})
+	sorted := make([]time.Time, len(indices))
+	for k, idx := range indices {
+		sorted[k] = times[idx]
+	}
+	return indices, sorted
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}